@@ -5,19 +5,88 @@ import (
 	"io/ioutil"
 )
 
+// Guard rejects prices from thin or manipulated pairs, zero values are not checked
+type Guard struct {
+	// MinReserveUSD is the minimum USD value of the price token reserve
+	MinReserveUSD float64
+	// MaxDeviationPerMinute is the maximum relative change per minute from the last accepted price
+	MaxDeviationPerMinute float64
+	// MaxTWAPDivergence is the maximum relative difference between spot price and TWAP
+	MaxTWAPDivergence float64
+	// TWAPWindow in seconds, 600 if not set
+	TWAPWindow int64
+}
+
 // Pair ...
 type Pair struct {
 	TargetTokenName string
 	TargetTokenAddr string
 	PriceTokenName  string
 	PriceTokenAddr  string
+	// Pool address for swap types without a factory, e.g. curve and balancer
+	Pool string
+	// TargetIndex and PriceIndex are the coin indexes of the tokens in Pool
+	TargetIndex int
+	PriceIndex  int
+	// Underlying uses coin 0 and the base pool coins of a curve meta pool
+	Underlying bool
+	// Stable selects the stable pair instead of the volatile one of a solidly factory
+	Stable bool
+	// Guard overrides Swap.Guard
+	Guard *Guard
+}
+
+// LPToken is a pair of the swap priced as a token itself
+type LPToken struct {
+	Name     string
+	PairAddr string
 }
 
 // Swap ...
 type Swap struct {
-	Name    string
+	Name string
+	// Type selects the dex adapter, uniswapv2 if not set
+	Type    string
 	Factory string
-	Pairs   []*Pair
+	// FeeBps is the swap fee in basis points, the default of Type if not set
+	FeeBps int64
+	// InitCodeHash of the pair contract, pair addresses are computed offline
+	// with CREATE2 if set instead of calling GetPair
+	InitCodeHash string
+	Pairs        []*Pair
+	LPTokens     []*LPToken
+	Guard        *Guard
+}
+
+// Depeg ...
+type Depeg struct {
+	// Reference is the stable coin the others are priced against
+	Reference string
+	// FiatPrice of Reference in USD, 1 if not set
+	FiatPrice float64
+	// Threshold is the relative deviation from 1 USD above which a stable coin counts as depegged
+	Threshold float64
+}
+
+// Arbitrage configures the background arbitrage scanner of a chain.
+// Scanning is off unless the chain sets it, e.g.
+//
+//	"Arbitrage": {"Interval": 30, "MinProfitUSD": 10}
+//
+// Each scan reads the reserves of every configured pair, so mind the load on the nodes.
+type Arbitrage struct {
+	// Interval between scans in seconds, 30 if not set
+	Interval int64
+	// MinProfitUSD is the minimum net profit of a reported opportunity
+	MinProfitUSD float64
+}
+
+// Native is the native asset of a chain, priced through its wrapped token
+type Native struct {
+	// Symbol of the native asset, e.g. eth, bnb, matic
+	Symbol string
+	// Wrapped is the address of the wrapped ERC20, e.g. WETH
+	Wrapped string
 }
 
 // Chain ...
@@ -26,12 +95,140 @@ type Chain struct {
 	Nodes       []string
 	Swaps       []*Swap
 	StableCoins []string
+	Depeg       *Depeg
+	// SupplyExclusions lists per token the holders (treasury, burn, vesting)
+	// whose balances are not part of the circulating supply
+	SupplyExclusions map[string][]string
+	// Arbitrage enables the arbitrage scanner if set
+	Arbitrage *Arbitrage
+	Native    *Native
+}
+
+// Log configures the logger
+type Log struct {
+	// Level is one of trace, debug, info, warn, error, crit, info if not set
+	Level string
+	// Format is one of logfmt, json, terminal, logfmt if not set
+	Format string
+}
+
+// Tracing configures the OpenTelemetry trace exporter
+type Tracing struct {
+	// Exporter is one of otlp, stdout, file
+	Exporter string
+	// Endpoint is the host:port of the OTLP/HTTP collector
+	Endpoint string
+	// Insecure disables TLS towards Endpoint
+	Insecure bool
+	// File spans are appended to by the file exporter
+	File string
+	// SampleRatio of the traces started here, 1 if not set
+	SampleRatio float64
+}
+
+// Health configures the readiness checks of /readyz
+type Health struct {
+	// Interval between background checks in seconds, 15 if not set
+	Interval int64
+	// MaxBlockAge is the age in seconds of the latest block of a chain beyond which it is stale, 120 if not set
+	MaxBlockAge int64
+	// MaxPriceAge is the age in seconds of the last price of a token beyond which it is stale, 300 if not set
+	MaxPriceAge int64
+	// Tokens that must have a fresh price, every configured token if not set
+	Tokens []string
+}
+
+// TLS configures https, both files are reloaded whenever they are modified
+type TLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+// CORS configures the cross origin headers for browser clients
+type CORS struct {
+	// Origins allowed, * allows any
+	Origins []string
+	// Methods allowed, GET and OPTIONS if not set
+	Methods []string
+	// Headers allowed in requests, Content-Type, X-API-Key and X-Request-ID if not set
+	Headers []string
+	// MaxAge in seconds preflight responses may be cached for, 600 if not set
+	MaxAge int64
+}
+
+// HTTP configures the http server, timeouts are in seconds
+type HTTP struct {
+	// Addr is the bind address of the Listen port, 0.0.0.0 if not set
+	Addr string
+	// UnixSocket is the path of a unix socket served instead of Addr
+	UnixSocket string
+	// TLS serves https if set
+	TLS *TLS
+	// CORS is disabled if not set
+	CORS *CORS
+	// ReadHeaderTimeout is 10 if not set
+	ReadHeaderTimeout int64
+	// ReadTimeout is 30 if not set
+	ReadTimeout int64
+	// WriteTimeout is unlimited if not set, as /arbitrage/stream is long lived
+	WriteTimeout int64
+	// IdleTimeout is 120 if not set
+	IdleTimeout int64
+	// ShutdownTimeout bounds draining in-flight requests on SIGINT/SIGTERM, 30 if not set
+	ShutdownTimeout int64
+	// RequestTimeout bounds the rpc calls of a request, except /arbitrage/stream, 30 if not set
+	RequestTimeout int64
+}
+
+// RPC configures the calls to Chain.Nodes
+type RPC struct {
+	// CallTimeout bounds every rpc call in seconds, 10 if not set
+	CallTimeout int64
+}
+
+// APIKey is a key accepted by the api in the X-API-Key header or the api_key query parameter
+type APIKey struct {
+	Key string
+	// Name identifies the key in usage counters and logs, a redacted Key if not set.
+	// It must be unique, so keys sharing their first 4 characters need one.
+	Name string
+	// Rate is the requests per second refilling the token bucket of the key, unlimited if not set
+	Rate float64
+	// Burst is the size of the token bucket, the ceiling of Rate if not set
+	Burst int
+	// Endpoints are the routes the key may call, e.g. /price/:tokens, every route if not set
+	Endpoints []string
+	// Admin keys may read the usage of every key at /admin/usage
+	Admin bool
+}
+
+// Auth enables api key authentication
+type Auth struct {
+	Keys []*APIKey
+	// KeysFile is a json array of more Keys, reloaded whenever it is modified
+	KeysFile string
+	// Public are the routes served without a key, /healthz, /readyz and /openapi.json if not set
+	Public []string
 }
 
 // Config ...
 type Config struct {
 	Listen uint16
+	// HTTP uses the defaults if not set
+	HTTP *HTTP
+	// RPC uses the defaults if not set
+	RPC    *RPC
 	Chains []*Chain
+	// FiatRates is a json file of the units of each fiat currency per USD, e.g. {"eur": 0.92},
+	// reloaded whenever it is modified
+	FiatRates string
+	Log       *Log
+	// Tracing is disabled if not set
+	Tracing *Tracing
+	// Health uses the defaults if not set
+	Health *Health
+	// Auth is disabled if not set
+	Auth *Auth
 }
 
 // LoadConfig ...
@@ -5,4 +5,10 @@ go 1.15
 require (
 	github.com/ethereum/go-ethereum v1.10.1
 	github.com/gin-gonic/gin v1.6.3
+	github.com/prometheus/client_golang v1.10.0
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.7.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 )
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
-	"fmt"
-	"log"
 
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/logger"
 	"github.com/zhiqiangxu/dex-price/pkg/server"
+	"github.com/zhiqiangxu/dex-price/pkg/tracing"
 )
 
 var confFile string
@@ -17,21 +19,50 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
 
 	conf, err := config.LoadConfig(confFile)
 	if err != nil {
-		log.Fatal("LoadConfig fail", err)
+		log.Crit("LoadConfig fail", "err", err)
 	}
 
+	err = logger.Setup(conf.Log)
+	if err != nil {
+		log.Crit("logger.Setup fail", "err", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(conf.Tracing)
+	if err != nil {
+		log.Crit("tracing.Setup fail", "err", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	{
-		confBytes, _ := json.MarshalIndent(conf, "", "    ")
-		fmt.Println("conf", string(confBytes))
+		// log a copy of the config with node urls and api keys redacted
+		var redacted config.Config
+		confBytes, _ := json.Marshal(conf)
+		json.Unmarshal(confBytes, &redacted)
+		for _, chain := range redacted.Chains {
+			for i, node := range chain.Nodes {
+				chain.Nodes[i] = logger.RedactURL(node)
+			}
+		}
+		if redacted.Auth != nil {
+			for _, key := range redacted.Auth.Keys {
+				key.Key = logger.RedactKey(key.Key)
+			}
+		}
+		confBytes, _ = json.Marshal(redacted)
+		log.Info("config loaded", "conf", string(confBytes))
 	}
 
 	s := server.New(conf)
 
 	err = s.Start()
+	if err != nil {
+		log.Error("server quit", "err", err)
+		return
+	}
 
-	fmt.Println("server quit", err)
-
+	log.Info("server quit")
 }
@@ -0,0 +1,296 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package balancer
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// IWeightedPoolABI is the input ABI used to generate the binding from.
+const IWeightedPoolABI = "[{\"type\":\"function\",\"name\":\"getPoolId\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}]},{\"type\":\"function\",\"name\":\"getVault\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\"}]},{\"type\":\"function\",\"name\":\"getNormalizedWeights\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256[]\"}]},{\"type\":\"function\",\"name\":\"getSwapFeePercentage\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]}]"
+
+// IWeightedPool is an auto generated Go binding around an Ethereum contract.
+type IWeightedPool struct {
+	IWeightedPoolCaller     // Read-only binding to the contract
+	IWeightedPoolTransactor // Write-only binding to the contract
+	IWeightedPoolFilterer   // Log filterer for contract events
+}
+
+// IWeightedPoolCaller is an auto generated read-only Go binding around an Ethereum contract.
+type IWeightedPoolCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IWeightedPoolTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type IWeightedPoolTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IWeightedPoolFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type IWeightedPoolFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IWeightedPoolSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type IWeightedPoolSession struct {
+	Contract     *IWeightedPool    // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// IWeightedPoolCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type IWeightedPoolCallerSession struct {
+	Contract *IWeightedPoolCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts        // Call options to use throughout this session
+}
+
+// IWeightedPoolTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type IWeightedPoolTransactorSession struct {
+	Contract     *IWeightedPoolTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts        // Transaction auth options to use throughout this session
+}
+
+// IWeightedPoolRaw is an auto generated low-level Go binding around an Ethereum contract.
+type IWeightedPoolRaw struct {
+	Contract *IWeightedPool // Generic contract binding to access the raw methods on
+}
+
+// IWeightedPoolCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type IWeightedPoolCallerRaw struct {
+	Contract *IWeightedPoolCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// IWeightedPoolTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type IWeightedPoolTransactorRaw struct {
+	Contract *IWeightedPoolTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewIWeightedPool creates a new instance of IWeightedPool, bound to a specific deployed contract.
+func NewIWeightedPool(address common.Address, backend bind.ContractBackend) (*IWeightedPool, error) {
+	contract, err := bindIWeightedPool(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &IWeightedPool{IWeightedPoolCaller: IWeightedPoolCaller{contract: contract}, IWeightedPoolTransactor: IWeightedPoolTransactor{contract: contract}, IWeightedPoolFilterer: IWeightedPoolFilterer{contract: contract}}, nil
+}
+
+// NewIWeightedPoolCaller creates a new read-only instance of IWeightedPool, bound to a specific deployed contract.
+func NewIWeightedPoolCaller(address common.Address, caller bind.ContractCaller) (*IWeightedPoolCaller, error) {
+	contract, err := bindIWeightedPool(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IWeightedPoolCaller{contract: contract}, nil
+}
+
+// NewIWeightedPoolTransactor creates a new write-only instance of IWeightedPool, bound to a specific deployed contract.
+func NewIWeightedPoolTransactor(address common.Address, transactor bind.ContractTransactor) (*IWeightedPoolTransactor, error) {
+	contract, err := bindIWeightedPool(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IWeightedPoolTransactor{contract: contract}, nil
+}
+
+// NewIWeightedPoolFilterer creates a new log filterer instance of IWeightedPool, bound to a specific deployed contract.
+func NewIWeightedPoolFilterer(address common.Address, filterer bind.ContractFilterer) (*IWeightedPoolFilterer, error) {
+	contract, err := bindIWeightedPool(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &IWeightedPoolFilterer{contract: contract}, nil
+}
+
+// bindIWeightedPool binds a generic wrapper to an already deployed contract.
+func bindIWeightedPool(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(IWeightedPoolABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IWeightedPool *IWeightedPoolRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IWeightedPool.Contract.IWeightedPoolCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IWeightedPool *IWeightedPoolRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IWeightedPool.Contract.IWeightedPoolTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IWeightedPool *IWeightedPoolRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IWeightedPool.Contract.IWeightedPoolTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IWeightedPool *IWeightedPoolCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IWeightedPool.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IWeightedPool *IWeightedPoolTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IWeightedPool.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IWeightedPool *IWeightedPoolTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IWeightedPool.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetNormalizedWeights is a free data retrieval call binding the contract method 0xf89f27ed.
+//
+// Solidity: function getNormalizedWeights() view returns(uint256[])
+func (_IWeightedPool *IWeightedPoolCaller) GetNormalizedWeights(opts *bind.CallOpts) ([]*big.Int, error) {
+	var out []interface{}
+	err := _IWeightedPool.contract.Call(opts, &out, "getNormalizedWeights")
+
+	if err != nil {
+		return *new([]*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([]*big.Int)).(*[]*big.Int)
+
+	return out0, err
+
+}
+
+// GetNormalizedWeights is a free data retrieval call binding the contract method 0xf89f27ed.
+//
+// Solidity: function getNormalizedWeights() view returns(uint256[])
+func (_IWeightedPool *IWeightedPoolSession) GetNormalizedWeights() ([]*big.Int, error) {
+	return _IWeightedPool.Contract.GetNormalizedWeights(&_IWeightedPool.CallOpts)
+}
+
+// GetNormalizedWeights is a free data retrieval call binding the contract method 0xf89f27ed.
+//
+// Solidity: function getNormalizedWeights() view returns(uint256[])
+func (_IWeightedPool *IWeightedPoolCallerSession) GetNormalizedWeights() ([]*big.Int, error) {
+	return _IWeightedPool.Contract.GetNormalizedWeights(&_IWeightedPool.CallOpts)
+}
+
+// GetPoolId is a free data retrieval call binding the contract method 0x38fff2d0.
+//
+// Solidity: function getPoolId() view returns(bytes32)
+func (_IWeightedPool *IWeightedPoolCaller) GetPoolId(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _IWeightedPool.contract.Call(opts, &out, "getPoolId")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// GetPoolId is a free data retrieval call binding the contract method 0x38fff2d0.
+//
+// Solidity: function getPoolId() view returns(bytes32)
+func (_IWeightedPool *IWeightedPoolSession) GetPoolId() ([32]byte, error) {
+	return _IWeightedPool.Contract.GetPoolId(&_IWeightedPool.CallOpts)
+}
+
+// GetPoolId is a free data retrieval call binding the contract method 0x38fff2d0.
+//
+// Solidity: function getPoolId() view returns(bytes32)
+func (_IWeightedPool *IWeightedPoolCallerSession) GetPoolId() ([32]byte, error) {
+	return _IWeightedPool.Contract.GetPoolId(&_IWeightedPool.CallOpts)
+}
+
+// GetSwapFeePercentage is a free data retrieval call binding the contract method 0x55c67628.
+//
+// Solidity: function getSwapFeePercentage() view returns(uint256)
+func (_IWeightedPool *IWeightedPoolCaller) GetSwapFeePercentage(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _IWeightedPool.contract.Call(opts, &out, "getSwapFeePercentage")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetSwapFeePercentage is a free data retrieval call binding the contract method 0x55c67628.
+//
+// Solidity: function getSwapFeePercentage() view returns(uint256)
+func (_IWeightedPool *IWeightedPoolSession) GetSwapFeePercentage() (*big.Int, error) {
+	return _IWeightedPool.Contract.GetSwapFeePercentage(&_IWeightedPool.CallOpts)
+}
+
+// GetSwapFeePercentage is a free data retrieval call binding the contract method 0x55c67628.
+//
+// Solidity: function getSwapFeePercentage() view returns(uint256)
+func (_IWeightedPool *IWeightedPoolCallerSession) GetSwapFeePercentage() (*big.Int, error) {
+	return _IWeightedPool.Contract.GetSwapFeePercentage(&_IWeightedPool.CallOpts)
+}
+
+// GetVault is a free data retrieval call binding the contract method 0x8d928af8.
+//
+// Solidity: function getVault() view returns(address)
+func (_IWeightedPool *IWeightedPoolCaller) GetVault(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _IWeightedPool.contract.Call(opts, &out, "getVault")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// GetVault is a free data retrieval call binding the contract method 0x8d928af8.
+//
+// Solidity: function getVault() view returns(address)
+func (_IWeightedPool *IWeightedPoolSession) GetVault() (common.Address, error) {
+	return _IWeightedPool.Contract.GetVault(&_IWeightedPool.CallOpts)
+}
+
+// GetVault is a free data retrieval call binding the contract method 0x8d928af8.
+//
+// Solidity: function getVault() view returns(address)
+func (_IWeightedPool *IWeightedPoolCallerSession) GetVault() (common.Address, error) {
+	return _IWeightedPool.Contract.GetVault(&_IWeightedPool.CallOpts)
+}
@@ -0,0 +1,542 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package balancer
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// IBalancerVaultABI is the input ABI used to generate the binding from.
+const IBalancerVaultABI = "[{\"type\":\"function\",\"name\":\"getPoolTokens\",\"stateMutability\":\"view\",\"inputs\":[{\"name\":\"poolId\",\"type\":\"bytes32\"}],\"outputs\":[{\"name\":\"tokens\",\"type\":\"address[]\"},{\"name\":\"balances\",\"type\":\"uint256[]\"},{\"name\":\"lastChangeBlock\",\"type\":\"uint256\"}]},{\"type\":\"event\",\"name\":\"Swap\",\"anonymous\":false,\"inputs\":[{\"name\":\"poolId\",\"type\":\"bytes32\",\"indexed\":true},{\"name\":\"tokenIn\",\"type\":\"address\",\"indexed\":true},{\"name\":\"tokenOut\",\"type\":\"address\",\"indexed\":true},{\"name\":\"amountIn\",\"type\":\"uint256\",\"indexed\":false},{\"name\":\"amountOut\",\"type\":\"uint256\",\"indexed\":false}]},{\"type\":\"event\",\"name\":\"PoolBalanceChanged\",\"anonymous\":false,\"inputs\":[{\"name\":\"poolId\",\"type\":\"bytes32\",\"indexed\":true},{\"name\":\"liquidityProvider\",\"type\":\"address\",\"indexed\":true},{\"name\":\"tokens\",\"type\":\"address[]\",\"indexed\":false},{\"name\":\"deltas\",\"type\":\"int256[]\",\"indexed\":false},{\"name\":\"protocolFeeAmounts\",\"type\":\"uint256[]\",\"indexed\":false}]}]"
+
+// IBalancerVault is an auto generated Go binding around an Ethereum contract.
+type IBalancerVault struct {
+	IBalancerVaultCaller     // Read-only binding to the contract
+	IBalancerVaultTransactor // Write-only binding to the contract
+	IBalancerVaultFilterer   // Log filterer for contract events
+}
+
+// IBalancerVaultCaller is an auto generated read-only Go binding around an Ethereum contract.
+type IBalancerVaultCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IBalancerVaultTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type IBalancerVaultTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IBalancerVaultFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type IBalancerVaultFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IBalancerVaultSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type IBalancerVaultSession struct {
+	Contract     *IBalancerVault   // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// IBalancerVaultCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type IBalancerVaultCallerSession struct {
+	Contract *IBalancerVaultCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts         // Call options to use throughout this session
+}
+
+// IBalancerVaultTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type IBalancerVaultTransactorSession struct {
+	Contract     *IBalancerVaultTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts         // Transaction auth options to use throughout this session
+}
+
+// IBalancerVaultRaw is an auto generated low-level Go binding around an Ethereum contract.
+type IBalancerVaultRaw struct {
+	Contract *IBalancerVault // Generic contract binding to access the raw methods on
+}
+
+// IBalancerVaultCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type IBalancerVaultCallerRaw struct {
+	Contract *IBalancerVaultCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// IBalancerVaultTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type IBalancerVaultTransactorRaw struct {
+	Contract *IBalancerVaultTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewIBalancerVault creates a new instance of IBalancerVault, bound to a specific deployed contract.
+func NewIBalancerVault(address common.Address, backend bind.ContractBackend) (*IBalancerVault, error) {
+	contract, err := bindIBalancerVault(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVault{IBalancerVaultCaller: IBalancerVaultCaller{contract: contract}, IBalancerVaultTransactor: IBalancerVaultTransactor{contract: contract}, IBalancerVaultFilterer: IBalancerVaultFilterer{contract: contract}}, nil
+}
+
+// NewIBalancerVaultCaller creates a new read-only instance of IBalancerVault, bound to a specific deployed contract.
+func NewIBalancerVaultCaller(address common.Address, caller bind.ContractCaller) (*IBalancerVaultCaller, error) {
+	contract, err := bindIBalancerVault(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVaultCaller{contract: contract}, nil
+}
+
+// NewIBalancerVaultTransactor creates a new write-only instance of IBalancerVault, bound to a specific deployed contract.
+func NewIBalancerVaultTransactor(address common.Address, transactor bind.ContractTransactor) (*IBalancerVaultTransactor, error) {
+	contract, err := bindIBalancerVault(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVaultTransactor{contract: contract}, nil
+}
+
+// NewIBalancerVaultFilterer creates a new log filterer instance of IBalancerVault, bound to a specific deployed contract.
+func NewIBalancerVaultFilterer(address common.Address, filterer bind.ContractFilterer) (*IBalancerVaultFilterer, error) {
+	contract, err := bindIBalancerVault(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVaultFilterer{contract: contract}, nil
+}
+
+// bindIBalancerVault binds a generic wrapper to an already deployed contract.
+func bindIBalancerVault(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(IBalancerVaultABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IBalancerVault *IBalancerVaultRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IBalancerVault.Contract.IBalancerVaultCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IBalancerVault *IBalancerVaultRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IBalancerVault.Contract.IBalancerVaultTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IBalancerVault *IBalancerVaultRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IBalancerVault.Contract.IBalancerVaultTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IBalancerVault *IBalancerVaultCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IBalancerVault.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IBalancerVault *IBalancerVaultTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IBalancerVault.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IBalancerVault *IBalancerVaultTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IBalancerVault.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetPoolTokens is a free data retrieval call binding the contract method 0xf94d4668.
+//
+// Solidity: function getPoolTokens(bytes32 poolId) view returns(address[] tokens, uint256[] balances, uint256 lastChangeBlock)
+func (_IBalancerVault *IBalancerVaultCaller) GetPoolTokens(opts *bind.CallOpts, poolId [32]byte) (struct {
+	Tokens          []common.Address
+	Balances        []*big.Int
+	LastChangeBlock *big.Int
+}, error) {
+	var out []interface{}
+	err := _IBalancerVault.contract.Call(opts, &out, "getPoolTokens", poolId)
+
+	outstruct := new(struct {
+		Tokens          []common.Address
+		Balances        []*big.Int
+		LastChangeBlock *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Tokens = *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address)
+	outstruct.Balances = *abi.ConvertType(out[1], new([]*big.Int)).(*[]*big.Int)
+	outstruct.LastChangeBlock = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// GetPoolTokens is a free data retrieval call binding the contract method 0xf94d4668.
+//
+// Solidity: function getPoolTokens(bytes32 poolId) view returns(address[] tokens, uint256[] balances, uint256 lastChangeBlock)
+func (_IBalancerVault *IBalancerVaultSession) GetPoolTokens(poolId [32]byte) (struct {
+	Tokens          []common.Address
+	Balances        []*big.Int
+	LastChangeBlock *big.Int
+}, error) {
+	return _IBalancerVault.Contract.GetPoolTokens(&_IBalancerVault.CallOpts, poolId)
+}
+
+// GetPoolTokens is a free data retrieval call binding the contract method 0xf94d4668.
+//
+// Solidity: function getPoolTokens(bytes32 poolId) view returns(address[] tokens, uint256[] balances, uint256 lastChangeBlock)
+func (_IBalancerVault *IBalancerVaultCallerSession) GetPoolTokens(poolId [32]byte) (struct {
+	Tokens          []common.Address
+	Balances        []*big.Int
+	LastChangeBlock *big.Int
+}, error) {
+	return _IBalancerVault.Contract.GetPoolTokens(&_IBalancerVault.CallOpts, poolId)
+}
+
+// IBalancerVaultPoolBalanceChangedIterator is returned from FilterPoolBalanceChanged and is used to iterate over the raw logs and unpacked data for PoolBalanceChanged events raised by the IBalancerVault contract.
+type IBalancerVaultPoolBalanceChangedIterator struct {
+	Event *IBalancerVaultPoolBalanceChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *IBalancerVaultPoolBalanceChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(IBalancerVaultPoolBalanceChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(IBalancerVaultPoolBalanceChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *IBalancerVaultPoolBalanceChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *IBalancerVaultPoolBalanceChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// IBalancerVaultPoolBalanceChanged represents a PoolBalanceChanged event raised by the IBalancerVault contract.
+type IBalancerVaultPoolBalanceChanged struct {
+	PoolId             [32]byte
+	LiquidityProvider  common.Address
+	Tokens             []common.Address
+	Deltas             []*big.Int
+	ProtocolFeeAmounts []*big.Int
+	Raw                types.Log // Blockchain specific contextual infos
+}
+
+// FilterPoolBalanceChanged is a free log retrieval operation binding the contract event 0xe5ce249087ce04f05a957192435400fd97868dba0e6a4b4c049abf8af80dae78.
+//
+// Solidity: event PoolBalanceChanged(bytes32 indexed poolId, address indexed liquidityProvider, address[] tokens, int256[] deltas, uint256[] protocolFeeAmounts)
+func (_IBalancerVault *IBalancerVaultFilterer) FilterPoolBalanceChanged(opts *bind.FilterOpts, poolId [][32]byte, liquidityProvider []common.Address) (*IBalancerVaultPoolBalanceChangedIterator, error) {
+
+	var poolIdRule []interface{}
+	for _, poolIdItem := range poolId {
+		poolIdRule = append(poolIdRule, poolIdItem)
+	}
+	var liquidityProviderRule []interface{}
+	for _, liquidityProviderItem := range liquidityProvider {
+		liquidityProviderRule = append(liquidityProviderRule, liquidityProviderItem)
+	}
+
+	logs, sub, err := _IBalancerVault.contract.FilterLogs(opts, "PoolBalanceChanged", poolIdRule, liquidityProviderRule)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVaultPoolBalanceChangedIterator{contract: _IBalancerVault.contract, event: "PoolBalanceChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchPoolBalanceChanged is a free log subscription operation binding the contract event 0xe5ce249087ce04f05a957192435400fd97868dba0e6a4b4c049abf8af80dae78.
+//
+// Solidity: event PoolBalanceChanged(bytes32 indexed poolId, address indexed liquidityProvider, address[] tokens, int256[] deltas, uint256[] protocolFeeAmounts)
+func (_IBalancerVault *IBalancerVaultFilterer) WatchPoolBalanceChanged(opts *bind.WatchOpts, sink chan<- *IBalancerVaultPoolBalanceChanged, poolId [][32]byte, liquidityProvider []common.Address) (event.Subscription, error) {
+
+	var poolIdRule []interface{}
+	for _, poolIdItem := range poolId {
+		poolIdRule = append(poolIdRule, poolIdItem)
+	}
+	var liquidityProviderRule []interface{}
+	for _, liquidityProviderItem := range liquidityProvider {
+		liquidityProviderRule = append(liquidityProviderRule, liquidityProviderItem)
+	}
+
+	logs, sub, err := _IBalancerVault.contract.WatchLogs(opts, "PoolBalanceChanged", poolIdRule, liquidityProviderRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(IBalancerVaultPoolBalanceChanged)
+				if err := _IBalancerVault.contract.UnpackLog(event, "PoolBalanceChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParsePoolBalanceChanged is a log parse operation binding the contract event 0xe5ce249087ce04f05a957192435400fd97868dba0e6a4b4c049abf8af80dae78.
+//
+// Solidity: event PoolBalanceChanged(bytes32 indexed poolId, address indexed liquidityProvider, address[] tokens, int256[] deltas, uint256[] protocolFeeAmounts)
+func (_IBalancerVault *IBalancerVaultFilterer) ParsePoolBalanceChanged(log types.Log) (*IBalancerVaultPoolBalanceChanged, error) {
+	event := new(IBalancerVaultPoolBalanceChanged)
+	if err := _IBalancerVault.contract.UnpackLog(event, "PoolBalanceChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// IBalancerVaultSwapIterator is returned from FilterSwap and is used to iterate over the raw logs and unpacked data for Swap events raised by the IBalancerVault contract.
+type IBalancerVaultSwapIterator struct {
+	Event *IBalancerVaultSwap // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *IBalancerVaultSwapIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(IBalancerVaultSwap)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(IBalancerVaultSwap)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *IBalancerVaultSwapIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *IBalancerVaultSwapIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// IBalancerVaultSwap represents a Swap event raised by the IBalancerVault contract.
+type IBalancerVaultSwap struct {
+	PoolId    [32]byte
+	TokenIn   common.Address
+	TokenOut  common.Address
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterSwap is a free log retrieval operation binding the contract event 0x2170c741c41531aec20e7c107c24eecfdd15e69c9bb0a8dd37b1840b9e0b207b.
+//
+// Solidity: event Swap(bytes32 indexed poolId, address indexed tokenIn, address indexed tokenOut, uint256 amountIn, uint256 amountOut)
+func (_IBalancerVault *IBalancerVaultFilterer) FilterSwap(opts *bind.FilterOpts, poolId [][32]byte, tokenIn []common.Address, tokenOut []common.Address) (*IBalancerVaultSwapIterator, error) {
+
+	var poolIdRule []interface{}
+	for _, poolIdItem := range poolId {
+		poolIdRule = append(poolIdRule, poolIdItem)
+	}
+	var tokenInRule []interface{}
+	for _, tokenInItem := range tokenIn {
+		tokenInRule = append(tokenInRule, tokenInItem)
+	}
+	var tokenOutRule []interface{}
+	for _, tokenOutItem := range tokenOut {
+		tokenOutRule = append(tokenOutRule, tokenOutItem)
+	}
+
+	logs, sub, err := _IBalancerVault.contract.FilterLogs(opts, "Swap", poolIdRule, tokenInRule, tokenOutRule)
+	if err != nil {
+		return nil, err
+	}
+	return &IBalancerVaultSwapIterator{contract: _IBalancerVault.contract, event: "Swap", logs: logs, sub: sub}, nil
+}
+
+// WatchSwap is a free log subscription operation binding the contract event 0x2170c741c41531aec20e7c107c24eecfdd15e69c9bb0a8dd37b1840b9e0b207b.
+//
+// Solidity: event Swap(bytes32 indexed poolId, address indexed tokenIn, address indexed tokenOut, uint256 amountIn, uint256 amountOut)
+func (_IBalancerVault *IBalancerVaultFilterer) WatchSwap(opts *bind.WatchOpts, sink chan<- *IBalancerVaultSwap, poolId [][32]byte, tokenIn []common.Address, tokenOut []common.Address) (event.Subscription, error) {
+
+	var poolIdRule []interface{}
+	for _, poolIdItem := range poolId {
+		poolIdRule = append(poolIdRule, poolIdItem)
+	}
+	var tokenInRule []interface{}
+	for _, tokenInItem := range tokenIn {
+		tokenInRule = append(tokenInRule, tokenInItem)
+	}
+	var tokenOutRule []interface{}
+	for _, tokenOutItem := range tokenOut {
+		tokenOutRule = append(tokenOutRule, tokenOutItem)
+	}
+
+	logs, sub, err := _IBalancerVault.contract.WatchLogs(opts, "Swap", poolIdRule, tokenInRule, tokenOutRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(IBalancerVaultSwap)
+				if err := _IBalancerVault.contract.UnpackLog(event, "Swap", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseSwap is a log parse operation binding the contract event 0x2170c741c41531aec20e7c107c24eecfdd15e69c9bb0a8dd37b1840b9e0b207b.
+//
+// Solidity: event Swap(bytes32 indexed poolId, address indexed tokenIn, address indexed tokenOut, uint256 amountIn, uint256 amountOut)
+func (_IBalancerVault *IBalancerVaultFilterer) ParseSwap(log types.Log) (*IBalancerVaultSwap, error) {
+	event := new(IBalancerVaultSwap)
+	if err := _IBalancerVault.contract.UnpackLog(event, "Swap", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
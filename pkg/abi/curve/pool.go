@@ -0,0 +1,716 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package curve
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ICurvePoolABI is the input ABI used to generate the binding from.
+const ICurvePoolABI = "[{\"name\":\"TokenExchange\",\"inputs\":[{\"type\":\"address\",\"name\":\"buyer\",\"indexed\":true},{\"type\":\"int128\",\"name\":\"sold_id\",\"indexed\":false},{\"type\":\"uint256\",\"name\":\"tokens_sold\",\"indexed\":false},{\"type\":\"int128\",\"name\":\"bought_id\",\"indexed\":false},{\"type\":\"uint256\",\"name\":\"tokens_bought\",\"indexed\":false}],\"anonymous\":false,\"type\":\"event\"},{\"name\":\"TokenExchangeUnderlying\",\"inputs\":[{\"type\":\"address\",\"name\":\"buyer\",\"indexed\":true},{\"type\":\"int128\",\"name\":\"sold_id\",\"indexed\":false},{\"type\":\"uint256\",\"name\":\"tokens_sold\",\"indexed\":false},{\"type\":\"int128\",\"name\":\"bought_id\",\"indexed\":false},{\"type\":\"uint256\",\"name\":\"tokens_bought\",\"indexed\":false}],\"anonymous\":false,\"type\":\"event\"},{\"name\":\"A\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"fee\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"coins\",\"outputs\":[{\"type\":\"address\",\"name\":\"\"}],\"inputs\":[{\"type\":\"uint256\",\"name\":\"arg0\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"balances\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[{\"type\":\"uint256\",\"name\":\"arg0\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"base_pool\",\"outputs\":[{\"type\":\"address\",\"name\":\"\"}],\"inputs\":[],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"get_virtual_price\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"get_dy\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[{\"type\":\"int128\",\"name\":\"i\"},{\"type\":\"int128\",\"name\":\"j\"},{\"type\":\"uint256\",\"name\":\"dx\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"name\":\"get_dy_underlying\",\"outputs\":[{\"type\":\"uint256\",\"name\":\"\"}],\"inputs\":[{\"type\":\"int128\",\"name\":\"i\"},{\"type\":\"int128\",\"name\":\"j\"},{\"type\":\"uint256\",\"name\":\"dx\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// ICurvePool is an auto generated Go binding around an Ethereum contract.
+type ICurvePool struct {
+	ICurvePoolCaller     // Read-only binding to the contract
+	ICurvePoolTransactor // Write-only binding to the contract
+	ICurvePoolFilterer   // Log filterer for contract events
+}
+
+// ICurvePoolCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ICurvePoolCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ICurvePoolTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ICurvePoolTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ICurvePoolFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ICurvePoolFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ICurvePoolSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ICurvePoolSession struct {
+	Contract     *ICurvePool       // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ICurvePoolCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ICurvePoolCallerSession struct {
+	Contract *ICurvePoolCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts     // Call options to use throughout this session
+}
+
+// ICurvePoolTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ICurvePoolTransactorSession struct {
+	Contract     *ICurvePoolTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts     // Transaction auth options to use throughout this session
+}
+
+// ICurvePoolRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ICurvePoolRaw struct {
+	Contract *ICurvePool // Generic contract binding to access the raw methods on
+}
+
+// ICurvePoolCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ICurvePoolCallerRaw struct {
+	Contract *ICurvePoolCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ICurvePoolTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ICurvePoolTransactorRaw struct {
+	Contract *ICurvePoolTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewICurvePool creates a new instance of ICurvePool, bound to a specific deployed contract.
+func NewICurvePool(address common.Address, backend bind.ContractBackend) (*ICurvePool, error) {
+	contract, err := bindICurvePool(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePool{ICurvePoolCaller: ICurvePoolCaller{contract: contract}, ICurvePoolTransactor: ICurvePoolTransactor{contract: contract}, ICurvePoolFilterer: ICurvePoolFilterer{contract: contract}}, nil
+}
+
+// NewICurvePoolCaller creates a new read-only instance of ICurvePool, bound to a specific deployed contract.
+func NewICurvePoolCaller(address common.Address, caller bind.ContractCaller) (*ICurvePoolCaller, error) {
+	contract, err := bindICurvePool(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePoolCaller{contract: contract}, nil
+}
+
+// NewICurvePoolTransactor creates a new write-only instance of ICurvePool, bound to a specific deployed contract.
+func NewICurvePoolTransactor(address common.Address, transactor bind.ContractTransactor) (*ICurvePoolTransactor, error) {
+	contract, err := bindICurvePool(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePoolTransactor{contract: contract}, nil
+}
+
+// NewICurvePoolFilterer creates a new log filterer instance of ICurvePool, bound to a specific deployed contract.
+func NewICurvePoolFilterer(address common.Address, filterer bind.ContractFilterer) (*ICurvePoolFilterer, error) {
+	contract, err := bindICurvePool(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePoolFilterer{contract: contract}, nil
+}
+
+// bindICurvePool binds a generic wrapper to an already deployed contract.
+func bindICurvePool(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(ICurvePoolABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ICurvePool *ICurvePoolRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ICurvePool.Contract.ICurvePoolCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ICurvePool *ICurvePoolRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ICurvePool.Contract.ICurvePoolTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ICurvePool *ICurvePoolRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ICurvePool.Contract.ICurvePoolTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ICurvePool *ICurvePoolCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ICurvePool.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ICurvePool *ICurvePoolTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ICurvePool.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ICurvePool *ICurvePoolTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ICurvePool.Contract.contract.Transact(opts, method, params...)
+}
+
+// A is a free data retrieval call binding the contract method 0xf446c1d0.
+//
+// Solidity: function A() view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) A(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "A")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// A is a free data retrieval call binding the contract method 0xf446c1d0.
+//
+// Solidity: function A() view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) A() (*big.Int, error) {
+	return _ICurvePool.Contract.A(&_ICurvePool.CallOpts)
+}
+
+// A is a free data retrieval call binding the contract method 0xf446c1d0.
+//
+// Solidity: function A() view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) A() (*big.Int, error) {
+	return _ICurvePool.Contract.A(&_ICurvePool.CallOpts)
+}
+
+// Balances is a free data retrieval call binding the contract method 0x4903b0d1.
+//
+// Solidity: function balances(uint256 arg0) view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) Balances(opts *bind.CallOpts, arg0 *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "balances", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Balances is a free data retrieval call binding the contract method 0x4903b0d1.
+//
+// Solidity: function balances(uint256 arg0) view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) Balances(arg0 *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.Balances(&_ICurvePool.CallOpts, arg0)
+}
+
+// Balances is a free data retrieval call binding the contract method 0x4903b0d1.
+//
+// Solidity: function balances(uint256 arg0) view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) Balances(arg0 *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.Balances(&_ICurvePool.CallOpts, arg0)
+}
+
+// BasePool is a free data retrieval call binding the contract method 0x5d6362bb.
+//
+// Solidity: function base_pool() view returns(address)
+func (_ICurvePool *ICurvePoolCaller) BasePool(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "base_pool")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// BasePool is a free data retrieval call binding the contract method 0x5d6362bb.
+//
+// Solidity: function base_pool() view returns(address)
+func (_ICurvePool *ICurvePoolSession) BasePool() (common.Address, error) {
+	return _ICurvePool.Contract.BasePool(&_ICurvePool.CallOpts)
+}
+
+// BasePool is a free data retrieval call binding the contract method 0x5d6362bb.
+//
+// Solidity: function base_pool() view returns(address)
+func (_ICurvePool *ICurvePoolCallerSession) BasePool() (common.Address, error) {
+	return _ICurvePool.Contract.BasePool(&_ICurvePool.CallOpts)
+}
+
+// Coins is a free data retrieval call binding the contract method 0xc6610657.
+//
+// Solidity: function coins(uint256 arg0) view returns(address)
+func (_ICurvePool *ICurvePoolCaller) Coins(opts *bind.CallOpts, arg0 *big.Int) (common.Address, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "coins", arg0)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Coins is a free data retrieval call binding the contract method 0xc6610657.
+//
+// Solidity: function coins(uint256 arg0) view returns(address)
+func (_ICurvePool *ICurvePoolSession) Coins(arg0 *big.Int) (common.Address, error) {
+	return _ICurvePool.Contract.Coins(&_ICurvePool.CallOpts, arg0)
+}
+
+// Coins is a free data retrieval call binding the contract method 0xc6610657.
+//
+// Solidity: function coins(uint256 arg0) view returns(address)
+func (_ICurvePool *ICurvePoolCallerSession) Coins(arg0 *big.Int) (common.Address, error) {
+	return _ICurvePool.Contract.Coins(&_ICurvePool.CallOpts, arg0)
+}
+
+// Fee is a free data retrieval call binding the contract method 0xddca3f43.
+//
+// Solidity: function fee() view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) Fee(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "fee")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Fee is a free data retrieval call binding the contract method 0xddca3f43.
+//
+// Solidity: function fee() view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) Fee() (*big.Int, error) {
+	return _ICurvePool.Contract.Fee(&_ICurvePool.CallOpts)
+}
+
+// Fee is a free data retrieval call binding the contract method 0xddca3f43.
+//
+// Solidity: function fee() view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) Fee() (*big.Int, error) {
+	return _ICurvePool.Contract.Fee(&_ICurvePool.CallOpts)
+}
+
+// GetDy is a free data retrieval call binding the contract method 0x5e0d443f.
+//
+// Solidity: function get_dy(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) GetDy(opts *bind.CallOpts, i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "get_dy", i, j, dx)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetDy is a free data retrieval call binding the contract method 0x5e0d443f.
+//
+// Solidity: function get_dy(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) GetDy(i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.GetDy(&_ICurvePool.CallOpts, i, j, dx)
+}
+
+// GetDy is a free data retrieval call binding the contract method 0x5e0d443f.
+//
+// Solidity: function get_dy(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) GetDy(i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.GetDy(&_ICurvePool.CallOpts, i, j, dx)
+}
+
+// GetDyUnderlying is a free data retrieval call binding the contract method 0x07211ef7.
+//
+// Solidity: function get_dy_underlying(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) GetDyUnderlying(opts *bind.CallOpts, i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "get_dy_underlying", i, j, dx)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetDyUnderlying is a free data retrieval call binding the contract method 0x07211ef7.
+//
+// Solidity: function get_dy_underlying(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) GetDyUnderlying(i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.GetDyUnderlying(&_ICurvePool.CallOpts, i, j, dx)
+}
+
+// GetDyUnderlying is a free data retrieval call binding the contract method 0x07211ef7.
+//
+// Solidity: function get_dy_underlying(int128 i, int128 j, uint256 dx) view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) GetDyUnderlying(i *big.Int, j *big.Int, dx *big.Int) (*big.Int, error) {
+	return _ICurvePool.Contract.GetDyUnderlying(&_ICurvePool.CallOpts, i, j, dx)
+}
+
+// GetVirtualPrice is a free data retrieval call binding the contract method 0xbb7b8b80.
+//
+// Solidity: function get_virtual_price() view returns(uint256)
+func (_ICurvePool *ICurvePoolCaller) GetVirtualPrice(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ICurvePool.contract.Call(opts, &out, "get_virtual_price")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetVirtualPrice is a free data retrieval call binding the contract method 0xbb7b8b80.
+//
+// Solidity: function get_virtual_price() view returns(uint256)
+func (_ICurvePool *ICurvePoolSession) GetVirtualPrice() (*big.Int, error) {
+	return _ICurvePool.Contract.GetVirtualPrice(&_ICurvePool.CallOpts)
+}
+
+// GetVirtualPrice is a free data retrieval call binding the contract method 0xbb7b8b80.
+//
+// Solidity: function get_virtual_price() view returns(uint256)
+func (_ICurvePool *ICurvePoolCallerSession) GetVirtualPrice() (*big.Int, error) {
+	return _ICurvePool.Contract.GetVirtualPrice(&_ICurvePool.CallOpts)
+}
+
+// ICurvePoolTokenExchangeIterator is returned from FilterTokenExchange and is used to iterate over the raw logs and unpacked data for TokenExchange events raised by the ICurvePool contract.
+type ICurvePoolTokenExchangeIterator struct {
+	Event *ICurvePoolTokenExchange // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ICurvePoolTokenExchangeIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ICurvePoolTokenExchange)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ICurvePoolTokenExchange)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ICurvePoolTokenExchangeIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ICurvePoolTokenExchangeIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ICurvePoolTokenExchange represents a TokenExchange event raised by the ICurvePool contract.
+type ICurvePoolTokenExchange struct {
+	Buyer        common.Address
+	SoldId       *big.Int
+	TokensSold   *big.Int
+	BoughtId     *big.Int
+	TokensBought *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterTokenExchange is a free log retrieval operation binding the contract event 0x8b3e96f2b889fa771c53c981b40daf005f63f637f1869f707052d15a3dd97140.
+//
+// Solidity: event TokenExchange(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) FilterTokenExchange(opts *bind.FilterOpts, buyer []common.Address) (*ICurvePoolTokenExchangeIterator, error) {
+
+	var buyerRule []interface{}
+	for _, buyerItem := range buyer {
+		buyerRule = append(buyerRule, buyerItem)
+	}
+
+	logs, sub, err := _ICurvePool.contract.FilterLogs(opts, "TokenExchange", buyerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePoolTokenExchangeIterator{contract: _ICurvePool.contract, event: "TokenExchange", logs: logs, sub: sub}, nil
+}
+
+// WatchTokenExchange is a free log subscription operation binding the contract event 0x8b3e96f2b889fa771c53c981b40daf005f63f637f1869f707052d15a3dd97140.
+//
+// Solidity: event TokenExchange(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) WatchTokenExchange(opts *bind.WatchOpts, sink chan<- *ICurvePoolTokenExchange, buyer []common.Address) (event.Subscription, error) {
+
+	var buyerRule []interface{}
+	for _, buyerItem := range buyer {
+		buyerRule = append(buyerRule, buyerItem)
+	}
+
+	logs, sub, err := _ICurvePool.contract.WatchLogs(opts, "TokenExchange", buyerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ICurvePoolTokenExchange)
+				if err := _ICurvePool.contract.UnpackLog(event, "TokenExchange", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTokenExchange is a log parse operation binding the contract event 0x8b3e96f2b889fa771c53c981b40daf005f63f637f1869f707052d15a3dd97140.
+//
+// Solidity: event TokenExchange(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) ParseTokenExchange(log types.Log) (*ICurvePoolTokenExchange, error) {
+	event := new(ICurvePoolTokenExchange)
+	if err := _ICurvePool.contract.UnpackLog(event, "TokenExchange", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ICurvePoolTokenExchangeUnderlyingIterator is returned from FilterTokenExchangeUnderlying and is used to iterate over the raw logs and unpacked data for TokenExchangeUnderlying events raised by the ICurvePool contract.
+type ICurvePoolTokenExchangeUnderlyingIterator struct {
+	Event *ICurvePoolTokenExchangeUnderlying // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ICurvePoolTokenExchangeUnderlyingIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ICurvePoolTokenExchangeUnderlying)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ICurvePoolTokenExchangeUnderlying)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ICurvePoolTokenExchangeUnderlyingIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ICurvePoolTokenExchangeUnderlyingIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ICurvePoolTokenExchangeUnderlying represents a TokenExchangeUnderlying event raised by the ICurvePool contract.
+type ICurvePoolTokenExchangeUnderlying struct {
+	Buyer        common.Address
+	SoldId       *big.Int
+	TokensSold   *big.Int
+	BoughtId     *big.Int
+	TokensBought *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterTokenExchangeUnderlying is a free log retrieval operation binding the contract event 0xd013ca23e77a65003c2c659c5442c00c805371b7fc1ebd4c206c41d1536bd90b.
+//
+// Solidity: event TokenExchangeUnderlying(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) FilterTokenExchangeUnderlying(opts *bind.FilterOpts, buyer []common.Address) (*ICurvePoolTokenExchangeUnderlyingIterator, error) {
+
+	var buyerRule []interface{}
+	for _, buyerItem := range buyer {
+		buyerRule = append(buyerRule, buyerItem)
+	}
+
+	logs, sub, err := _ICurvePool.contract.FilterLogs(opts, "TokenExchangeUnderlying", buyerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ICurvePoolTokenExchangeUnderlyingIterator{contract: _ICurvePool.contract, event: "TokenExchangeUnderlying", logs: logs, sub: sub}, nil
+}
+
+// WatchTokenExchangeUnderlying is a free log subscription operation binding the contract event 0xd013ca23e77a65003c2c659c5442c00c805371b7fc1ebd4c206c41d1536bd90b.
+//
+// Solidity: event TokenExchangeUnderlying(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) WatchTokenExchangeUnderlying(opts *bind.WatchOpts, sink chan<- *ICurvePoolTokenExchangeUnderlying, buyer []common.Address) (event.Subscription, error) {
+
+	var buyerRule []interface{}
+	for _, buyerItem := range buyer {
+		buyerRule = append(buyerRule, buyerItem)
+	}
+
+	logs, sub, err := _ICurvePool.contract.WatchLogs(opts, "TokenExchangeUnderlying", buyerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ICurvePoolTokenExchangeUnderlying)
+				if err := _ICurvePool.contract.UnpackLog(event, "TokenExchangeUnderlying", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTokenExchangeUnderlying is a log parse operation binding the contract event 0xd013ca23e77a65003c2c659c5442c00c805371b7fc1ebd4c206c41d1536bd90b.
+//
+// Solidity: event TokenExchangeUnderlying(address indexed buyer, int128 sold_id, uint256 tokens_sold, int128 bought_id, uint256 tokens_bought)
+func (_ICurvePool *ICurvePoolFilterer) ParseTokenExchangeUnderlying(log types.Log) (*ICurvePoolTokenExchangeUnderlying, error) {
+	event := new(ICurvePoolTokenExchangeUnderlying)
+	if err := _ICurvePool.contract.UnpackLog(event, "TokenExchangeUnderlying", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
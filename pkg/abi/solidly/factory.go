@@ -0,0 +1,203 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package solidly
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ISolidlyFactoryABI is the input ABI used to generate the binding from.
+const ISolidlyFactoryABI = "[{\"type\":\"function\",\"name\":\"getPair\",\"stateMutability\":\"view\",\"inputs\":[{\"name\":\"tokenA\",\"type\":\"address\"},{\"name\":\"tokenB\",\"type\":\"address\"},{\"name\":\"stable\",\"type\":\"bool\"}],\"outputs\":[{\"name\":\"\",\"type\":\"address\"}]}]"
+
+// ISolidlyFactory is an auto generated Go binding around an Ethereum contract.
+type ISolidlyFactory struct {
+	ISolidlyFactoryCaller     // Read-only binding to the contract
+	ISolidlyFactoryTransactor // Write-only binding to the contract
+	ISolidlyFactoryFilterer   // Log filterer for contract events
+}
+
+// ISolidlyFactoryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ISolidlyFactoryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyFactoryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ISolidlyFactoryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyFactoryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ISolidlyFactoryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyFactorySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ISolidlyFactorySession struct {
+	Contract     *ISolidlyFactory  // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ISolidlyFactoryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ISolidlyFactoryCallerSession struct {
+	Contract *ISolidlyFactoryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts          // Call options to use throughout this session
+}
+
+// ISolidlyFactoryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ISolidlyFactoryTransactorSession struct {
+	Contract     *ISolidlyFactoryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts          // Transaction auth options to use throughout this session
+}
+
+// ISolidlyFactoryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ISolidlyFactoryRaw struct {
+	Contract *ISolidlyFactory // Generic contract binding to access the raw methods on
+}
+
+// ISolidlyFactoryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ISolidlyFactoryCallerRaw struct {
+	Contract *ISolidlyFactoryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ISolidlyFactoryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ISolidlyFactoryTransactorRaw struct {
+	Contract *ISolidlyFactoryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewISolidlyFactory creates a new instance of ISolidlyFactory, bound to a specific deployed contract.
+func NewISolidlyFactory(address common.Address, backend bind.ContractBackend) (*ISolidlyFactory, error) {
+	contract, err := bindISolidlyFactory(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyFactory{ISolidlyFactoryCaller: ISolidlyFactoryCaller{contract: contract}, ISolidlyFactoryTransactor: ISolidlyFactoryTransactor{contract: contract}, ISolidlyFactoryFilterer: ISolidlyFactoryFilterer{contract: contract}}, nil
+}
+
+// NewISolidlyFactoryCaller creates a new read-only instance of ISolidlyFactory, bound to a specific deployed contract.
+func NewISolidlyFactoryCaller(address common.Address, caller bind.ContractCaller) (*ISolidlyFactoryCaller, error) {
+	contract, err := bindISolidlyFactory(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyFactoryCaller{contract: contract}, nil
+}
+
+// NewISolidlyFactoryTransactor creates a new write-only instance of ISolidlyFactory, bound to a specific deployed contract.
+func NewISolidlyFactoryTransactor(address common.Address, transactor bind.ContractTransactor) (*ISolidlyFactoryTransactor, error) {
+	contract, err := bindISolidlyFactory(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyFactoryTransactor{contract: contract}, nil
+}
+
+// NewISolidlyFactoryFilterer creates a new log filterer instance of ISolidlyFactory, bound to a specific deployed contract.
+func NewISolidlyFactoryFilterer(address common.Address, filterer bind.ContractFilterer) (*ISolidlyFactoryFilterer, error) {
+	contract, err := bindISolidlyFactory(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyFactoryFilterer{contract: contract}, nil
+}
+
+// bindISolidlyFactory binds a generic wrapper to an already deployed contract.
+func bindISolidlyFactory(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(ISolidlyFactoryABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ISolidlyFactory *ISolidlyFactoryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ISolidlyFactory.Contract.ISolidlyFactoryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ISolidlyFactory *ISolidlyFactoryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ISolidlyFactory.Contract.ISolidlyFactoryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ISolidlyFactory *ISolidlyFactoryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ISolidlyFactory.Contract.ISolidlyFactoryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ISolidlyFactory *ISolidlyFactoryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ISolidlyFactory.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ISolidlyFactory *ISolidlyFactoryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ISolidlyFactory.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ISolidlyFactory *ISolidlyFactoryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ISolidlyFactory.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetPair is a free data retrieval call binding the contract method 0x6801cc30.
+//
+// Solidity: function getPair(address tokenA, address tokenB, bool stable) view returns(address)
+func (_ISolidlyFactory *ISolidlyFactoryCaller) GetPair(opts *bind.CallOpts, tokenA common.Address, tokenB common.Address, stable bool) (common.Address, error) {
+	var out []interface{}
+	err := _ISolidlyFactory.contract.Call(opts, &out, "getPair", tokenA, tokenB, stable)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// GetPair is a free data retrieval call binding the contract method 0x6801cc30.
+//
+// Solidity: function getPair(address tokenA, address tokenB, bool stable) view returns(address)
+func (_ISolidlyFactory *ISolidlyFactorySession) GetPair(tokenA common.Address, tokenB common.Address, stable bool) (common.Address, error) {
+	return _ISolidlyFactory.Contract.GetPair(&_ISolidlyFactory.CallOpts, tokenA, tokenB, stable)
+}
+
+// GetPair is a free data retrieval call binding the contract method 0x6801cc30.
+//
+// Solidity: function getPair(address tokenA, address tokenB, bool stable) view returns(address)
+func (_ISolidlyFactory *ISolidlyFactoryCallerSession) GetPair(tokenA common.Address, tokenB common.Address, stable bool) (common.Address, error) {
+	return _ISolidlyFactory.Contract.GetPair(&_ISolidlyFactory.CallOpts, tokenA, tokenB, stable)
+}
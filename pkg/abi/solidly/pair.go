@@ -0,0 +1,481 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package solidly
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ISolidlyPairABI is the input ABI used to generate the binding from.
+const ISolidlyPairABI = "[{\"type\":\"function\",\"name\":\"token0\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\"}]},{\"type\":\"function\",\"name\":\"token1\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\"}]},{\"type\":\"function\",\"name\":\"stable\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}]},{\"type\":\"function\",\"name\":\"totalSupply\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}]},{\"type\":\"function\",\"name\":\"getReserves\",\"stateMutability\":\"view\",\"inputs\":[],\"outputs\":[{\"name\":\"_reserve0\",\"type\":\"uint256\"},{\"name\":\"_reserve1\",\"type\":\"uint256\"},{\"name\":\"_blockTimestampLast\",\"type\":\"uint256\"}]},{\"type\":\"event\",\"name\":\"Sync\",\"anonymous\":false,\"inputs\":[{\"name\":\"reserve0\",\"type\":\"uint256\",\"indexed\":false},{\"name\":\"reserve1\",\"type\":\"uint256\",\"indexed\":false}]}]"
+
+// ISolidlyPair is an auto generated Go binding around an Ethereum contract.
+type ISolidlyPair struct {
+	ISolidlyPairCaller     // Read-only binding to the contract
+	ISolidlyPairTransactor // Write-only binding to the contract
+	ISolidlyPairFilterer   // Log filterer for contract events
+}
+
+// ISolidlyPairCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ISolidlyPairCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyPairTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ISolidlyPairTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyPairFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ISolidlyPairFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ISolidlyPairSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ISolidlyPairSession struct {
+	Contract     *ISolidlyPair     // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ISolidlyPairCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ISolidlyPairCallerSession struct {
+	Contract *ISolidlyPairCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts       // Call options to use throughout this session
+}
+
+// ISolidlyPairTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ISolidlyPairTransactorSession struct {
+	Contract     *ISolidlyPairTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts       // Transaction auth options to use throughout this session
+}
+
+// ISolidlyPairRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ISolidlyPairRaw struct {
+	Contract *ISolidlyPair // Generic contract binding to access the raw methods on
+}
+
+// ISolidlyPairCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ISolidlyPairCallerRaw struct {
+	Contract *ISolidlyPairCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ISolidlyPairTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ISolidlyPairTransactorRaw struct {
+	Contract *ISolidlyPairTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewISolidlyPair creates a new instance of ISolidlyPair, bound to a specific deployed contract.
+func NewISolidlyPair(address common.Address, backend bind.ContractBackend) (*ISolidlyPair, error) {
+	contract, err := bindISolidlyPair(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyPair{ISolidlyPairCaller: ISolidlyPairCaller{contract: contract}, ISolidlyPairTransactor: ISolidlyPairTransactor{contract: contract}, ISolidlyPairFilterer: ISolidlyPairFilterer{contract: contract}}, nil
+}
+
+// NewISolidlyPairCaller creates a new read-only instance of ISolidlyPair, bound to a specific deployed contract.
+func NewISolidlyPairCaller(address common.Address, caller bind.ContractCaller) (*ISolidlyPairCaller, error) {
+	contract, err := bindISolidlyPair(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyPairCaller{contract: contract}, nil
+}
+
+// NewISolidlyPairTransactor creates a new write-only instance of ISolidlyPair, bound to a specific deployed contract.
+func NewISolidlyPairTransactor(address common.Address, transactor bind.ContractTransactor) (*ISolidlyPairTransactor, error) {
+	contract, err := bindISolidlyPair(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyPairTransactor{contract: contract}, nil
+}
+
+// NewISolidlyPairFilterer creates a new log filterer instance of ISolidlyPair, bound to a specific deployed contract.
+func NewISolidlyPairFilterer(address common.Address, filterer bind.ContractFilterer) (*ISolidlyPairFilterer, error) {
+	contract, err := bindISolidlyPair(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyPairFilterer{contract: contract}, nil
+}
+
+// bindISolidlyPair binds a generic wrapper to an already deployed contract.
+func bindISolidlyPair(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(ISolidlyPairABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ISolidlyPair *ISolidlyPairRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ISolidlyPair.Contract.ISolidlyPairCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ISolidlyPair *ISolidlyPairRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ISolidlyPair.Contract.ISolidlyPairTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ISolidlyPair *ISolidlyPairRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ISolidlyPair.Contract.ISolidlyPairTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ISolidlyPair *ISolidlyPairCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ISolidlyPair.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ISolidlyPair *ISolidlyPairTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ISolidlyPair.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ISolidlyPair *ISolidlyPairTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ISolidlyPair.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetReserves is a free data retrieval call binding the contract method 0x0902f1ac.
+//
+// Solidity: function getReserves() view returns(uint256 _reserve0, uint256 _reserve1, uint256 _blockTimestampLast)
+func (_ISolidlyPair *ISolidlyPairCaller) GetReserves(opts *bind.CallOpts) (struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast *big.Int
+}, error) {
+	var out []interface{}
+	err := _ISolidlyPair.contract.Call(opts, &out, "getReserves")
+
+	outstruct := new(struct {
+		Reserve0           *big.Int
+		Reserve1           *big.Int
+		BlockTimestampLast *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Reserve0 = *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+	outstruct.Reserve1 = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	outstruct.BlockTimestampLast = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// GetReserves is a free data retrieval call binding the contract method 0x0902f1ac.
+//
+// Solidity: function getReserves() view returns(uint256 _reserve0, uint256 _reserve1, uint256 _blockTimestampLast)
+func (_ISolidlyPair *ISolidlyPairSession) GetReserves() (struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast *big.Int
+}, error) {
+	return _ISolidlyPair.Contract.GetReserves(&_ISolidlyPair.CallOpts)
+}
+
+// GetReserves is a free data retrieval call binding the contract method 0x0902f1ac.
+//
+// Solidity: function getReserves() view returns(uint256 _reserve0, uint256 _reserve1, uint256 _blockTimestampLast)
+func (_ISolidlyPair *ISolidlyPairCallerSession) GetReserves() (struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast *big.Int
+}, error) {
+	return _ISolidlyPair.Contract.GetReserves(&_ISolidlyPair.CallOpts)
+}
+
+// Stable is a free data retrieval call binding the contract method 0x22be3de1.
+//
+// Solidity: function stable() view returns(bool)
+func (_ISolidlyPair *ISolidlyPairCaller) Stable(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	err := _ISolidlyPair.contract.Call(opts, &out, "stable")
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// Stable is a free data retrieval call binding the contract method 0x22be3de1.
+//
+// Solidity: function stable() view returns(bool)
+func (_ISolidlyPair *ISolidlyPairSession) Stable() (bool, error) {
+	return _ISolidlyPair.Contract.Stable(&_ISolidlyPair.CallOpts)
+}
+
+// Stable is a free data retrieval call binding the contract method 0x22be3de1.
+//
+// Solidity: function stable() view returns(bool)
+func (_ISolidlyPair *ISolidlyPairCallerSession) Stable() (bool, error) {
+	return _ISolidlyPair.Contract.Stable(&_ISolidlyPair.CallOpts)
+}
+
+// Token0 is a free data retrieval call binding the contract method 0x0dfe1681.
+//
+// Solidity: function token0() view returns(address)
+func (_ISolidlyPair *ISolidlyPairCaller) Token0(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _ISolidlyPair.contract.Call(opts, &out, "token0")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Token0 is a free data retrieval call binding the contract method 0x0dfe1681.
+//
+// Solidity: function token0() view returns(address)
+func (_ISolidlyPair *ISolidlyPairSession) Token0() (common.Address, error) {
+	return _ISolidlyPair.Contract.Token0(&_ISolidlyPair.CallOpts)
+}
+
+// Token0 is a free data retrieval call binding the contract method 0x0dfe1681.
+//
+// Solidity: function token0() view returns(address)
+func (_ISolidlyPair *ISolidlyPairCallerSession) Token0() (common.Address, error) {
+	return _ISolidlyPair.Contract.Token0(&_ISolidlyPair.CallOpts)
+}
+
+// Token1 is a free data retrieval call binding the contract method 0xd21220a7.
+//
+// Solidity: function token1() view returns(address)
+func (_ISolidlyPair *ISolidlyPairCaller) Token1(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _ISolidlyPair.contract.Call(opts, &out, "token1")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Token1 is a free data retrieval call binding the contract method 0xd21220a7.
+//
+// Solidity: function token1() view returns(address)
+func (_ISolidlyPair *ISolidlyPairSession) Token1() (common.Address, error) {
+	return _ISolidlyPair.Contract.Token1(&_ISolidlyPair.CallOpts)
+}
+
+// Token1 is a free data retrieval call binding the contract method 0xd21220a7.
+//
+// Solidity: function token1() view returns(address)
+func (_ISolidlyPair *ISolidlyPairCallerSession) Token1() (common.Address, error) {
+	return _ISolidlyPair.Contract.Token1(&_ISolidlyPair.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_ISolidlyPair *ISolidlyPairCaller) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _ISolidlyPair.contract.Call(opts, &out, "totalSupply")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_ISolidlyPair *ISolidlyPairSession) TotalSupply() (*big.Int, error) {
+	return _ISolidlyPair.Contract.TotalSupply(&_ISolidlyPair.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_ISolidlyPair *ISolidlyPairCallerSession) TotalSupply() (*big.Int, error) {
+	return _ISolidlyPair.Contract.TotalSupply(&_ISolidlyPair.CallOpts)
+}
+
+// ISolidlyPairSyncIterator is returned from FilterSync and is used to iterate over the raw logs and unpacked data for Sync events raised by the ISolidlyPair contract.
+type ISolidlyPairSyncIterator struct {
+	Event *ISolidlyPairSync // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ISolidlyPairSyncIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ISolidlyPairSync)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ISolidlyPairSync)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ISolidlyPairSyncIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ISolidlyPairSyncIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ISolidlyPairSync represents a Sync event raised by the ISolidlyPair contract.
+type ISolidlyPairSync struct {
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterSync is a free log retrieval operation binding the contract event 0xcf2aa50876cdfbb541206f89af0ee78d44a2abf8d328e37fa4917f982149848a.
+//
+// Solidity: event Sync(uint256 reserve0, uint256 reserve1)
+func (_ISolidlyPair *ISolidlyPairFilterer) FilterSync(opts *bind.FilterOpts) (*ISolidlyPairSyncIterator, error) {
+
+	logs, sub, err := _ISolidlyPair.contract.FilterLogs(opts, "Sync")
+	if err != nil {
+		return nil, err
+	}
+	return &ISolidlyPairSyncIterator{contract: _ISolidlyPair.contract, event: "Sync", logs: logs, sub: sub}, nil
+}
+
+// WatchSync is a free log subscription operation binding the contract event 0xcf2aa50876cdfbb541206f89af0ee78d44a2abf8d328e37fa4917f982149848a.
+//
+// Solidity: event Sync(uint256 reserve0, uint256 reserve1)
+func (_ISolidlyPair *ISolidlyPairFilterer) WatchSync(opts *bind.WatchOpts, sink chan<- *ISolidlyPairSync) (event.Subscription, error) {
+
+	logs, sub, err := _ISolidlyPair.contract.WatchLogs(opts, "Sync")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ISolidlyPairSync)
+				if err := _ISolidlyPair.contract.UnpackLog(event, "Sync", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseSync is a log parse operation binding the contract event 0xcf2aa50876cdfbb541206f89af0ee78d44a2abf8d328e37fa4917f982149848a.
+//
+// Solidity: event Sync(uint256 reserve0, uint256 reserve1)
+func (_ISolidlyPair *ISolidlyPairFilterer) ParseSync(log types.Log) (*ISolidlyPairSync, error) {
+	event := new(ISolidlyPairSync)
+	if err := _ISolidlyPair.contract.UnpackLog(event, "Sync", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
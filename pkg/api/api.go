@@ -0,0 +1,175 @@
+// Package api holds the request and response types of the dex-price http api,
+// shared by the server and the client
+package api
+
+// BaseResp ...
+type BaseResp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// per token status in TokenPrice
+const (
+	StatusOK          = "ok"
+	StatusNotFound    = "not_found"
+	StatusRPCError    = "rpc_error"
+	StatusNoLiquidity = "no_liquidity"
+	StatusRejected    = "rejected"
+	StatusTimeout     = "timeout"
+)
+
+// TokenPrice ...
+type TokenPrice struct {
+	Symbol   string   `json:"symbol"`
+	Price    float64  `json:"price"`
+	Status   string   `json:"status"`
+	Msg      string   `json:"msg,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PriceResult ...
+type PriceResult struct {
+	BaseResp
+	// Quote is the unit of every price
+	Quote  string       `json:"quote"`
+	Prices []TokenPrice `json:"prices"`
+}
+
+// TokensResult ...
+type TokensResult struct {
+	BaseResp
+	Tokens []string `json:"tokens"`
+}
+
+// PoolToken ...
+type PoolToken struct {
+	Symbol     string  `json:"symbol"`
+	Addr       string  `json:"addr"`
+	Decimals   uint8   `json:"decimals"`
+	RawReserve string  `json:"raw_reserve"`
+	Reserve    float64 `json:"reserve"`
+	Price      float64 `json:"price"`
+}
+
+// PoolInfo ...
+type PoolInfo struct {
+	Swap               string    `json:"swap"`
+	Pair               string    `json:"pair"`
+	Token0             PoolToken `json:"token0"`
+	Token1             PoolToken `json:"token1"`
+	TVL                float64   `json:"tvl"`
+	TotalSupply        string    `json:"total_supply,omitempty"`
+	KLast              string    `json:"k_last,omitempty"`
+	BlockTimestampLast uint32    `json:"block_timestamp_last,omitempty"`
+}
+
+// PoolsResult ...
+type PoolsResult struct {
+	BaseResp
+	Pools []PoolInfo `json:"pools"`
+}
+
+// Holding ...
+type Holding struct {
+	// Symbol is native-<symbol> for the native balance, which has no Addr
+	Symbol     string   `json:"symbol"`
+	Addr       string   `json:"addr,omitempty"`
+	RawBalance string   `json:"raw_balance"`
+	Balance    float64  `json:"balance"`
+	Price      float64  `json:"price"`
+	Value      float64  `json:"value"`
+	Status     string   `json:"status"`
+	Msg        string   `json:"msg,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// PortfolioResult ...
+type PortfolioResult struct {
+	BaseResp
+	Wallet     string    `json:"wallet"`
+	Block      uint64    `json:"block,omitempty"`
+	Holdings   []Holding `json:"holdings"`
+	Unpriced   []Holding `json:"unpriced"`
+	TotalValue float64   `json:"total_value"`
+}
+
+// MarketCap ...
+type MarketCap struct {
+	Symbol            string   `json:"symbol"`
+	Price             float64  `json:"price"`
+	TotalSupply       float64  `json:"total_supply"`
+	CirculatingSupply float64  `json:"circulating_supply"`
+	MarketCap         float64  `json:"market_cap"`
+	FDV               float64  `json:"fdv"`
+	Status            string   `json:"status"`
+	Msg               string   `json:"msg,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+}
+
+// MarketCapResult ...
+type MarketCapResult struct {
+	BaseResp
+	Quote      string      `json:"quote"`
+	MarketCaps []MarketCap `json:"market_caps"`
+}
+
+// arbitrage kinds
+const (
+	ArbitrageCrossSwap  = "cross_swap"
+	ArbitrageTriangular = "triangular"
+)
+
+// ArbitrageHop ...
+type ArbitrageHop struct {
+	Swap     string `json:"swap"`
+	Pair     string `json:"pair"`
+	TokenIn  string `json:"token_in"`
+	TokenOut string `json:"token_out"`
+}
+
+// ArbitrageOpportunity ...
+type ArbitrageOpportunity struct {
+	Kind      string         `json:"kind"`
+	Chain     string         `json:"chain"`
+	Token     string         `json:"token"`
+	Hops      []ArbitrageHop `json:"hops"`
+	AmountIn  float64        `json:"amount_in"`
+	Profit    float64        `json:"profit"`
+	ProfitUSD float64        `json:"profit_usd"`
+	TS        int64          `json:"ts"`
+}
+
+// ArbitrageResult ...
+type ArbitrageResult struct {
+	BaseResp
+	Opportunities []ArbitrageOpportunity `json:"opportunities"`
+}
+
+// FailingComponent is a chain or token that keeps the server from being ready
+type FailingComponent struct {
+	// Component is chain:<name> or token:<name>
+	Component string `json:"component"`
+	Msg       string `json:"msg"`
+}
+
+// ReadyResult ...
+type ReadyResult struct {
+	BaseResp
+	Ready   bool               `json:"ready"`
+	Failing []FailingComponent `json:"failing,omitempty"`
+}
+
+// KeyUsage counts the requests of an api key
+type KeyUsage struct {
+	Name        string `json:"name"`
+	Requests    uint64 `json:"requests"`
+	RateLimited uint64 `json:"rate_limited"`
+	Forbidden   uint64 `json:"forbidden"`
+	LastUsed    int64  `json:"last_used"`
+}
+
+// UsageResult ...
+type UsageResult struct {
+	BaseResp
+	Keys []KeyUsage `json:"keys"`
+}
@@ -0,0 +1,351 @@
+// Package client is a typed Go client of the dex-price http api
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+)
+
+const (
+	defaultRetries = 2
+	defaultBackoff = 200 * time.Millisecond
+	apiKeyHeader   = "X-API-Key"
+)
+
+// Client ...
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithRetries retries a failed request up to retries times, waiting backoff doubled after each attempt,
+// or the Retry-After of a 429 if longer
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a Client of the server at baseURL, e.g. http://127.0.0.1:8899
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is a response with an unexpected status
+type Error struct {
+	StatusCode int
+	Msg        string
+	// RetryAfter is set on 429
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("status %d:%s", e.StatusCode, e.Msg)
+}
+
+// retryable is true for statuses a later attempt may not get
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// QueryOptions of Prices and MarketCaps
+type QueryOptions struct {
+	// Quote is a configured token or fiat currency, usd if empty
+	Quote string
+	// Strict fails the request on the first token that fails
+	Strict bool
+}
+
+func (o *QueryOptions) values() url.Values {
+	values := url.Values{}
+	if o == nil {
+		return values
+	}
+	if o.Quote != "" {
+		values.Set("quote", o.Quote)
+	}
+	if o.Strict {
+		values.Set("strict", "true")
+	}
+	return values
+}
+
+func tokensPath(tokens []string) string {
+	escaped := make([]string, len(tokens))
+	for i, token := range tokens {
+		escaped[i] = url.PathEscape(token)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// Prices of tokens
+func (c *Client) Prices(ctx context.Context, tokens []string, opts *QueryOptions) (result *api.PriceResult, err error) {
+	result = &api.PriceResult{}
+	err = c.get(ctx, "/price/"+tokensPath(tokens), opts.values(), result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Tokens that can be priced
+func (c *Client) Tokens(ctx context.Context) (result *api.TokensResult, err error) {
+	result = &api.TokensResult{}
+	err = c.get(ctx, "/tokens", nil, result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Pools on the route from token to its stable coin
+func (c *Client) Pools(ctx context.Context, token string) (result *api.PoolsResult, err error) {
+	result = &api.PoolsResult{}
+	err = c.get(ctx, "/pools/"+url.PathEscape(token), nil, result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Portfolio of wallet on chain at block, the latest block if 0
+func (c *Client) Portfolio(ctx context.Context, chain, wallet string, block uint64) (result *api.PortfolioResult, err error) {
+	values := url.Values{}
+	if block > 0 {
+		values.Set("block", strconv.FormatUint(block, 10))
+	}
+	result = &api.PortfolioResult{}
+	err = c.get(ctx, "/portfolio/"+url.PathEscape(chain)+"/"+url.PathEscape(wallet), values, result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// MarketCaps of tokens
+func (c *Client) MarketCaps(ctx context.Context, tokens []string, opts *QueryOptions) (result *api.MarketCapResult, err error) {
+	result = &api.MarketCapResult{}
+	err = c.get(ctx, "/marketcap/"+tokensPath(tokens), opts.values(), result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Arbitrage opportunities of the last scan of every chain
+func (c *Client) Arbitrage(ctx context.Context) (result *api.ArbitrageResult, err error) {
+	result = &api.ArbitrageResult{}
+	err = c.get(ctx, "/arbitrage", nil, result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Health returns nil if the server process is alive
+func (c *Client) Health(ctx context.Context) (err error) {
+	var result api.BaseResp
+	err = c.get(ctx, "/healthz", nil, &result)
+	return
+}
+
+// Ready returns the readiness of the server, a not ready server is not an error
+func (c *Client) Ready(ctx context.Context) (result *api.ReadyResult, err error) {
+	result = &api.ReadyResult{}
+	err = c.get(ctx, "/readyz", nil, result, http.StatusServiceUnavailable)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// Usage of every api key, requires an admin key
+func (c *Client) Usage(ctx context.Context) (result *api.UsageResult, err error) {
+	result = &api.UsageResult{}
+	err = c.get(ctx, "/admin/usage", nil, result)
+	if err != nil {
+		result = nil
+	}
+	return
+}
+
+// StreamArbitrage calls fn with the opportunities of every scan until ctx is done or the server ends the stream.
+// The stream is not retried.
+func (c *Client) StreamArbitrage(ctx context.Context, fn func([]api.ArbitrageOpportunity)) (err error) {
+	req, err := c.newRequest(ctx, "/arbitrage/stream", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("Do fail:%w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = responseError(resp)
+		return
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16*1024*1024)
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(line, "data:"))
+		case line == "" && data.Len() > 0:
+			var opportunities []api.ArbitrageOpportunity
+			err = json.Unmarshal(data.Bytes(), &opportunities)
+			if err != nil {
+				err = fmt.Errorf("arbitrage event Unmarshal fail:%v", err)
+				return
+			}
+			data.Reset()
+			fn(opportunities)
+		}
+	}
+	err = scanner.Err()
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+func (c *Client) newRequest(ctx context.Context, path string, query url.Values) (req *http.Request, err error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		err = fmt.Errorf("NewRequest fail:%v", err)
+		return
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	return
+}
+
+// get decodes the json response of path into out, statuses besides 200 in accept are decoded as well
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}, accept ...int) (err error) {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		var wait time.Duration
+		retry, wait, err = c.do(ctx, path, query, out, accept)
+		if err == nil || !retry || attempt >= c.retries {
+			return
+		}
+
+		if wait < backoff {
+			wait = backoff
+		}
+		backoff *= 2
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// do makes a single attempt of get, retry is true if a later attempt may succeed
+func (c *Client) do(ctx context.Context, path string, query url.Values, out interface{}, accept []int) (retry bool, wait time.Duration, err error) {
+	req, err := c.newRequest(ctx, path, query)
+	if err != nil {
+		return
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("Do fail:%w", err)
+		retry = ctx.Err() == nil
+		return
+	}
+	defer resp.Body.Close()
+
+	ok := resp.StatusCode == http.StatusOK
+	for _, status := range accept {
+		ok = ok || resp.StatusCode == status
+	}
+	if !ok {
+		apiErr := responseError(resp)
+		err = apiErr
+		retry = retryable(resp.StatusCode)
+		wait = apiErr.RetryAfter
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		err = fmt.Errorf("%s Decode fail:%v", path, err)
+	}
+	return
+}
+
+func responseError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	body, _ := ioutil.ReadAll(resp.Body)
+	var msg struct {
+		Msg string `json:"msg"`
+	}
+	if json.Unmarshal(body, &msg) == nil && msg.Msg != "" {
+		apiErr.Msg = msg.Msg
+	} else {
+		apiErr.Msg = strings.TrimSpace(string(body))
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
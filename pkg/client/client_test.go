@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestPrices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/price/eth,uni" || r.URL.Query().Get("quote") != "WETH" || r.URL.Query().Get("strict") != "true" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"msg": "unexpected request " + r.URL.String()})
+			return
+		}
+		if r.Header.Get(apiKeyHeader) != "secret" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"msg": "api key required"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.PriceResult{
+			BaseResp: api.BaseResp{Code: http.StatusOK},
+			Quote:    "WETH",
+			Prices:   []api.TokenPrice{{Symbol: "eth", Price: 1, Status: api.StatusOK}, {Symbol: "uni", Price: 0.01, Status: api.StatusOK}},
+		})
+	}))
+	defer srv.Close()
+
+	result, err := New(srv.URL+"/", WithAPIKey("secret")).Prices(context.Background(), []string{"eth", "uni"}, &QueryOptions{Quote: "WETH", Strict: true})
+	if err != nil {
+		t.Fatalf("Prices fail:%v", err)
+	}
+	if result.Quote != "WETH" || len(result.Prices) != 2 || result.Prices[1].Symbol != "uni" || result.Prices[1].Price != 0.01 {
+		t.Fatalf("unexpected result:%+v", result)
+	}
+
+	_, err = New(srv.URL).Prices(context.Background(), []string{"eth", "uni"}, &QueryOptions{Quote: "WETH", Strict: true})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Msg != "api key required" {
+		t.Fatalf("Prices without a key:%v, want a 401 Error", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		ok       bool
+	}{
+		{"retried until ok", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3, true},
+		{"retries exhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, 3, false},
+		{"not retryable", []int{http.StatusBadRequest, http.StatusOK}, 1, false},
+	}
+	for _, c := range cases {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := c.statuses[atomic.AddInt32(&attempts, 1)-1]
+			writeJSON(w, status, api.TokensResult{BaseResp: api.BaseResp{Code: status}, Tokens: []string{"eth"}})
+		}))
+		_, err := New(srv.URL, WithRetries(2, time.Millisecond)).Tokens(context.Background())
+		srv.Close()
+		if (err == nil) != c.ok || attempts != c.attempts {
+			t.Fatalf("%s: err %v after %d attempts, want ok %v after %d", c.name, err, attempts, c.ok, c.attempts)
+		}
+	}
+}
+
+func TestReadyNotReady(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, api.ReadyResult{
+			BaseResp: api.BaseResp{Code: http.StatusServiceUnavailable},
+			Failing:  []api.FailingComponent{{Component: "chain:eth", Msg: "rpc call failed"}},
+		})
+	}))
+	defer srv.Close()
+
+	result, err := New(srv.URL).Ready(context.Background())
+	if err != nil {
+		t.Fatalf("Ready fail:%v", err)
+	}
+	if result.Ready || len(result.Failing) != 1 {
+		t.Fatalf("unexpected result:%+v", result)
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.BaseResp{Code: http.StatusOK})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := New(srv.URL).Health(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Health with a canceled context:%v, want context.Canceled", err)
+	}
+}
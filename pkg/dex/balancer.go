@@ -0,0 +1,252 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/balancer"
+)
+
+// balancerMaxInRatio is the largest share of the balance the vault accepts as amount in
+const balancerMaxInRatio = 0.3
+
+// balancerOne is the fixed point 1 of weights and fees
+var balancerOne = big.NewFloat(1e18)
+
+// balancerPool is the Pool.Meta of a Balancer weighted pool
+type balancerPool struct {
+	id    [32]byte
+	vault common.Address
+}
+
+// balancerState is the State.Meta of a Balancer weighted pool
+type balancerState struct {
+	// normalized weights in Tokens order, summing up to 1
+	weights []float64
+	// fee is the swap fee as a fraction of the input
+	fee float64
+}
+
+// balancerSwap prices Balancer V2 weighted pools, whose balances are held by the vault.
+// Pools are configured per pair with Pair.Pool.
+type balancerSwap struct {
+	swap    *config.Swap
+	backend bind.ContractCaller
+}
+
+func newBalancer(swap *config.Swap, backend bind.ContractCaller) (d *balancerSwap, err error) {
+	d = &balancerSwap{swap: swap, backend: backend}
+	return
+}
+
+func (d *balancerSwap) ResolvePool(opts *bind.CallOpts, pair *config.Pair) (pool *Pool, err error) {
+	if pair.Pool == "" {
+		err = fmt.Errorf("pair(%s/%s) has no balancer pool configured, %w", pair.TargetTokenName, pair.PriceTokenName, ErrNoPool)
+		return
+	}
+
+	pool, err = d.LoadPool(opts, common.HexToAddress(pair.Pool))
+	if err != nil {
+		return
+	}
+
+	if pool.Index(common.HexToAddress(pair.TargetTokenAddr)) < 0 || pool.Index(common.HexToAddress(pair.PriceTokenAddr)) < 0 {
+		err = fmt.Errorf("invalid pair for %s", pair.TargetTokenName)
+		pool = nil
+		return
+	}
+	return
+}
+
+func (d *balancerSwap) LoadPool(opts *bind.CallOpts, addr common.Address) (pool *Pool, err error) {
+	poolContract, err := balancer.NewIWeightedPoolCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIWeightedPoolCaller fail:%v", err)
+		return
+	}
+
+	meta := &balancerPool{}
+	meta.id, err = poolContract.GetPoolId(opts)
+	if err != nil {
+		err = fmt.Errorf("getPoolId fail:%w", err)
+		return
+	}
+	meta.vault, err = poolContract.GetVault(opts)
+	if err != nil {
+		err = fmt.Errorf("getVault fail:%w", err)
+		return
+	}
+
+	vault, err := balancer.NewIBalancerVaultCaller(meta.vault, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIBalancerVaultCaller fail:%v", err)
+		return
+	}
+	poolTokens, err := vault.GetPoolTokens(opts, meta.id)
+	if err != nil {
+		err = fmt.Errorf("getPoolTokens fail:%w", err)
+		return
+	}
+
+	pool = &Pool{Address: addr, Tokens: poolTokens.Tokens, Meta: meta}
+	pool.Decimals, err = loadDecimals(opts, d.backend, pool.Tokens)
+	if err != nil {
+		pool = nil
+	}
+	return
+}
+
+// State reads the balances from the vault, weights and fee from the pool since managed pools may change them
+func (d *balancerSwap) State(opts *bind.CallOpts, pool *Pool) (state *State, err error) {
+	meta := pool.Meta.(*balancerPool)
+	vault, err := balancer.NewIBalancerVaultCaller(meta.vault, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIBalancerVaultCaller fail:%v", err)
+		return
+	}
+	poolTokens, err := vault.GetPoolTokens(opts, meta.id)
+	if err != nil {
+		err = fmt.Errorf("getPoolTokens fail:%w", err)
+		return
+	}
+
+	poolContract, err := balancer.NewIWeightedPoolCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIWeightedPoolCaller fail:%v", err)
+		return
+	}
+	weights, err := poolContract.GetNormalizedWeights(opts)
+	if err != nil {
+		err = fmt.Errorf("getNormalizedWeights fail:%w", err)
+		return
+	}
+	fee, err := poolContract.GetSwapFeePercentage(opts)
+	if err != nil {
+		err = fmt.Errorf("getSwapFeePercentage fail:%w", err)
+		return
+	}
+	if len(weights) != len(pool.Tokens) || len(poolTokens.Balances) != len(pool.Tokens) {
+		err = fmt.Errorf("pool %s has %d weights and %d balances for %d tokens", pool.Address.Hex(), len(weights), len(poolTokens.Balances), len(pool.Tokens))
+		return
+	}
+
+	bs := &balancerState{fee: balancerFixed(fee)}
+	for _, weight := range weights {
+		bs.weights = append(bs.weights, balancerFixed(weight))
+	}
+
+	state = &State{Reserves: poolTokens.Balances, Meta: bs}
+	return
+}
+
+// SpotPrice is the Balancer spot price (Bi/Wi)/(Bo/Wo)/(1-fee), base being the token out
+func (d *balancerSwap) SpotPrice(pool *Pool, state *State, base, quote int) (price float64, err error) {
+	if state.Reserves[base].Sign() == 0 || state.Reserves[quote].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	bs := state.Meta.(*balancerState)
+	quoteBalance := DecimalAdjust(state.Reserves[quote], pool.Decimals[quote])
+	baseBalance := DecimalAdjust(state.Reserves[base], pool.Decimals[base])
+	price = (quoteBalance / bs.weights[quote]) / (baseBalance / bs.weights[base]) / (1 - bs.fee)
+	return
+}
+
+// Quote implements WeightedMath._calcOutGivenIn, bO * (1 - (bI/(bI+aI*(1-fee)))^(wI/wO))
+func (d *balancerSwap) Quote(opts *bind.CallOpts, pool *Pool, state *State, in, out int, amountIn *big.Int) (amountOut *big.Int, err error) {
+	if state.Reserves[in].Sign() == 0 || state.Reserves[out].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	bs := state.Meta.(*balancerState)
+	balanceIn, _ := big.NewFloat(0).SetInt(state.Reserves[in]).Float64()
+	balanceOut := big.NewFloat(0).SetInt(state.Reserves[out])
+	amount, _ := big.NewFloat(0).SetInt(amountIn).Float64()
+	if amount > balanceIn*balancerMaxInRatio {
+		err = fmt.Errorf("amount in exceeds %v of the balance", balancerMaxInRatio)
+		return
+	}
+
+	// 1 - (bI/(bI+a))^e == -expm1(-e*log1p(a/bI)), precise for small trades
+	amount *= 1 - bs.fee
+	share := -math.Expm1(-bs.weights[in] / bs.weights[out] * math.Log1p(amount/balanceIn))
+	amountOut, _ = balanceOut.Mul(balanceOut, big.NewFloat(share)).Int(nil)
+	return
+}
+
+// Subscribe reloads the state of pool on every swap or join/exit in the vault
+func (d *balancerSwap) Subscribe(ctx context.Context, pool *Pool, sink chan<- *Update) (sub event.Subscription, err error) {
+	filterer, ok := d.backend.(bind.ContractFilterer)
+	if !ok {
+		err = ErrSubscribeNotSupported
+		return
+	}
+
+	meta := pool.Meta.(*balancerPool)
+	vaultFilterer, err := balancer.NewIBalancerVaultFilterer(meta.vault, filterer)
+	if err != nil {
+		err = fmt.Errorf("NewIBalancerVaultFilterer fail:%v", err)
+		return
+	}
+
+	swaps := make(chan *balancer.IBalancerVaultSwap)
+	swapSub, err := vaultFilterer.WatchSwap(&bind.WatchOpts{Context: ctx}, swaps, [][32]byte{meta.id}, nil, nil)
+	if err != nil {
+		err = fmt.Errorf("WatchSwap fail:%w", err)
+		return
+	}
+	changes := make(chan *balancer.IBalancerVaultPoolBalanceChanged)
+	changeSub, err := vaultFilterer.WatchPoolBalanceChanged(&bind.WatchOpts{Context: ctx}, changes, [][32]byte{meta.id}, nil)
+	if err != nil {
+		swapSub.Unsubscribe()
+		err = fmt.Errorf("WatchPoolBalanceChanged fail:%w", err)
+		return
+	}
+
+	sub = event.NewSubscription(func(quit <-chan struct{}) error {
+		defer swapSub.Unsubscribe()
+		defer changeSub.Unsubscribe()
+		for {
+			var blockNumber uint64
+			select {
+			case swap := <-swaps:
+				blockNumber = swap.Raw.BlockNumber
+			case change := <-changes:
+				blockNumber = change.Raw.BlockNumber
+			case err := <-swapSub.Err():
+				return err
+			case err := <-changeSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+
+			state, err := d.State(&bind.CallOpts{Context: ctx, BlockNumber: big.NewInt(0).SetUint64(blockNumber)}, pool)
+			if err != nil {
+				return err
+			}
+			select {
+			case sink <- &Update{Pool: pool, State: state, BlockNumber: blockNumber}:
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return
+}
+
+// balancerFixed converts an 18 decimals fixed point number to float64
+func balancerFixed(x *big.Int) float64 {
+	f, _ := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(x), balancerOne).Float64()
+	return f
+}
+
+var _ Dex = (*balancerSwap)(nil)
@@ -0,0 +1,501 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/curve"
+)
+
+const (
+	// curveMaxCoins is the largest number of coins in a Curve pool
+	curveMaxCoins = 8
+	// curveFeeDenominator is the precision of the Curve fee
+	curveFeeDenominator = 1e10
+	// stableSwapIterations bounds the Newton iterations of the invariant
+	stableSwapIterations = 255
+)
+
+var curvePrecision = big.NewInt(1e18)
+
+// curvePool is the Pool.Meta of a Curve pool
+type curvePool struct {
+	// n is the number of coins of the pool itself
+	n int
+	// base is the base pool of a meta pool, whose LP token is the last coin
+	base common.Address
+	// baseN is the number of coins of the base pool
+	baseN int
+	// underlying is set when Tokens are coin 0 followed by the base pool coins
+	underlying bool
+}
+
+// curveState is the State.Meta of a Curve pool
+type curveState struct {
+	amp *big.Int
+	fee *big.Int
+	// balances of the pool itself, differs from Reserves for underlying pools
+	balances []*big.Int
+	// virtualPrice of the base pool, nil for plain pools
+	virtualPrice *big.Int
+	// prices of each underlying token in token 0, fee excluded, only for underlying pools
+	prices []float64
+}
+
+// curveSwap prices Curve StableSwap plain and meta pools.
+// Pools are not discovered through a factory but configured per pair with Pair.Pool.
+type curveSwap struct {
+	swap    *config.Swap
+	backend bind.ContractCaller
+}
+
+func newCurve(swap *config.Swap, backend bind.ContractCaller) (d *curveSwap, err error) {
+	d = &curveSwap{swap: swap, backend: backend}
+	return
+}
+
+func (d *curveSwap) ResolvePool(opts *bind.CallOpts, pair *config.Pair) (pool *Pool, err error) {
+	if pair.Pool == "" {
+		err = fmt.Errorf("pair(%s/%s) has no curve pool configured, %w", pair.TargetTokenName, pair.PriceTokenName, ErrNoPool)
+		return
+	}
+
+	pool, err = d.LoadPool(opts, common.HexToAddress(pair.Pool))
+	if err != nil {
+		return
+	}
+
+	if pair.Underlying {
+		meta := pool.Meta.(*curvePool)
+		if meta.base == (common.Address{}) {
+			err = fmt.Errorf("pool %s of %s is not a meta pool", pair.Pool, pair.TargetTokenName)
+			pool = nil
+			return
+		}
+		var baseTokens []common.Address
+		baseTokens, err = d.coins(opts, meta.base)
+		if err != nil {
+			pool = nil
+			return
+		}
+		var baseDecimals []uint8
+		baseDecimals, err = loadDecimals(opts, d.backend, baseTokens)
+		if err != nil {
+			pool = nil
+			return
+		}
+		meta.baseN = len(baseTokens)
+		meta.underlying = true
+		pool.Tokens = append([]common.Address{pool.Tokens[0]}, baseTokens...)
+		pool.Decimals = append([]uint8{pool.Decimals[0]}, baseDecimals...)
+	}
+
+	if pair.TargetIndex >= len(pool.Tokens) || pair.PriceIndex >= len(pool.Tokens) ||
+		pool.Tokens[pair.TargetIndex] != common.HexToAddress(pair.TargetTokenAddr) ||
+		pool.Tokens[pair.PriceIndex] != common.HexToAddress(pair.PriceTokenAddr) {
+		err = fmt.Errorf("coin indexes %d/%d of pool %s do not match %s/%s", pair.TargetIndex, pair.PriceIndex, pair.Pool, pair.TargetTokenName, pair.PriceTokenName)
+		pool = nil
+		return
+	}
+	return
+}
+
+// coins lists the coins of a Curve pool, coins(i) reverts past the last coin.
+// Any other failure is returned, a pool missing a coin would price wrong for as long as it is cached.
+func (d *curveSwap) coins(opts *bind.CallOpts, addr common.Address) (coins []common.Address, err error) {
+	poolContract, err := curve.NewICurvePoolCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+
+	for i := 0; i < curveMaxCoins; i++ {
+		coin, callErr := poolContract.Coins(opts, big.NewInt(int64(i)))
+		if callErr != nil {
+			// every pool has at least 2 coins, so only later reverts mark the end
+			if i < 2 || !isRevert(callErr) {
+				err = fmt.Errorf("coins(%d) fail:%w", i, callErr)
+				return
+			}
+			break
+		}
+		if coin == (common.Address{}) {
+			break
+		}
+		coins = append(coins, coin)
+	}
+	if len(coins) < 2 {
+		err = fmt.Errorf("%s is not a curve pool", addr.Hex())
+	}
+	return
+}
+
+func (d *curveSwap) LoadPool(opts *bind.CallOpts, addr common.Address) (pool *Pool, err error) {
+	coins, err := d.coins(opts, addr)
+	if err != nil {
+		return
+	}
+	decimals, err := loadDecimals(opts, d.backend, coins)
+	if err != nil {
+		return
+	}
+
+	meta := &curvePool{n: len(coins)}
+	poolContract, err := curve.NewICurvePoolCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+	// plain pools have no base_pool and revert
+	meta.base, err = poolContract.BasePool(opts)
+	if err != nil {
+		if !isRevert(err) {
+			err = fmt.Errorf("base_pool fail:%w", err)
+			return
+		}
+		err = nil
+	}
+
+	pool = &Pool{Address: addr, Tokens: coins, Decimals: decimals, Meta: meta}
+	return
+}
+
+func (d *curveSwap) State(opts *bind.CallOpts, pool *Pool) (state *State, err error) {
+	meta := pool.Meta.(*curvePool)
+	poolContract, err := curve.NewICurvePoolCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+
+	cs := &curveState{}
+	cs.amp, err = poolContract.A(opts)
+	if err != nil {
+		err = fmt.Errorf("A fail:%w", err)
+		return
+	}
+	cs.fee, err = poolContract.Fee(opts)
+	if err != nil {
+		err = fmt.Errorf("fee fail:%w", err)
+		return
+	}
+	cs.balances, err = d.balances(opts, pool.Address, meta.n)
+	if err != nil {
+		return
+	}
+
+	state = &State{Reserves: cs.balances, Meta: cs}
+	if meta.base == (common.Address{}) {
+		return
+	}
+
+	baseContract, err := curve.NewICurvePoolCaller(meta.base, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+	cs.virtualPrice, err = baseContract.GetVirtualPrice(opts)
+	if err != nil {
+		err = fmt.Errorf("base get_virtual_price fail:%w", err)
+		return
+	}
+	if !meta.underlying {
+		return
+	}
+
+	// the share of each base pool coin owned by the meta pool, the base pool
+	// holds D ~ sum(xp) of value and the meta pool holds balance*virtualPrice of it
+	baseBalances, err := d.balances(opts, meta.base, meta.baseN)
+	if err != nil {
+		return
+	}
+	baseXp := big.NewInt(0)
+	for i, balance := range baseBalances {
+		baseXp.Add(baseXp, big.NewInt(0).Mul(balance, plainRate(pool.Decimals[i+1])))
+	}
+	baseXp.Div(baseXp, curvePrecision)
+	state.Reserves = []*big.Int{cs.balances[0]}
+	for _, balance := range baseBalances {
+		reserve := big.NewInt(0)
+		if baseXp.Sign() > 0 {
+			reserve.Mul(balance, cs.balances[meta.n-1])
+			reserve.Mul(reserve, cs.virtualPrice)
+			reserve.Div(reserve, curvePrecision)
+			reserve.Div(reserve, baseXp)
+		}
+		state.Reserves = append(state.Reserves, reserve)
+	}
+
+	// price every underlying coin in coin 0 on chain, one whole coin at a time
+	cs.prices = []float64{1}
+	feeMultiplier := 1 - float64(cs.fee.Int64())/curveFeeDenominator
+	for i := 1; i < len(pool.Tokens); i++ {
+		dx := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(pool.Decimals[i])), nil)
+		var dy *big.Int
+		dy, err = poolContract.GetDyUnderlying(opts, big.NewInt(int64(i)), big.NewInt(0), dx)
+		if err != nil {
+			err = fmt.Errorf("get_dy_underlying fail:%w", err)
+			return
+		}
+		cs.prices = append(cs.prices, DecimalAdjust(dy, pool.Decimals[0])/feeMultiplier)
+	}
+	return
+}
+
+func (d *curveSwap) balances(opts *bind.CallOpts, addr common.Address, n int) (balances []*big.Int, err error) {
+	poolContract, err := curve.NewICurvePoolCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		var balance *big.Int
+		balance, err = poolContract.Balances(opts, big.NewInt(int64(i)))
+		if err != nil {
+			err = fmt.Errorf("balances fail:%w", err)
+			return
+		}
+		balances = append(balances, balance)
+	}
+	return
+}
+
+// rates normalizes balances to 18 decimals, the LP coin of a meta pool is valued at the base virtual price
+func (d *curveSwap) rates(pool *Pool, cs *curveState) (rates []*big.Int) {
+	meta := pool.Meta.(*curvePool)
+	for i := 0; i < meta.n; i++ {
+		if i == meta.n-1 && cs.virtualPrice != nil {
+			rates = append(rates, cs.virtualPrice)
+		} else {
+			rates = append(rates, plainRate(pool.Decimals[i]))
+		}
+	}
+	return
+}
+
+// plainRate is the rate that scales a coin with decimals to 18 decimals
+func plainRate(decimals uint8) *big.Int {
+	return big.NewInt(0).Exp(big.NewInt(10), big.NewInt(36-int64(decimals)), nil)
+}
+
+// SpotPrice evaluates the StableSwap invariant off chain for a marginal trade,
+// underlying pools use the rates measured on chain by State
+func (d *curveSwap) SpotPrice(pool *Pool, state *State, base, quote int) (price float64, err error) {
+	cs := state.Meta.(*curveState)
+	if state.Reserves[base].Sign() == 0 || state.Reserves[quote].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	if cs.prices != nil {
+		price = cs.prices[base] / cs.prices[quote]
+		return
+	}
+
+	rates := d.rates(pool, cs)
+	xp := make([]*big.Int, len(cs.balances))
+	for i, balance := range cs.balances {
+		xp[i] = big.NewInt(0).Div(big.NewInt(0).Mul(balance, rates[i]), curvePrecision)
+	}
+
+	// a trade of a millionth of the balance approximates the marginal price
+	dx := big.NewInt(0).Div(xp[base], big.NewInt(1e6))
+	if dx.Sign() == 0 {
+		dx.SetInt64(1)
+	}
+	y, err := stableSwapY(cs.amp, base, quote, big.NewInt(0).Add(xp[base], dx), xp)
+	if err != nil {
+		return
+	}
+	dy := big.NewInt(0).Sub(xp[quote], y)
+
+	// back from 18 decimals xp to whole tokens
+	dxTokens := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(dx), big.NewFloat(0).SetInt(rates[base]))
+	dyTokens := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(dy), big.NewFloat(0).SetInt(rates[quote]))
+	dxTokens.Mul(dxTokens, Pow10(18))
+	dxTokens.Quo(dxTokens, Pow10(pool.Decimals[base]))
+	dyTokens.Mul(dyTokens, Pow10(18))
+	dyTokens.Quo(dyTokens, Pow10(pool.Decimals[quote]))
+	price, _ = big.NewFloat(0).Quo(dyTokens, dxTokens).Float64()
+	return
+}
+
+// Quote calls get_dy, or get_dy_underlying for underlying pools
+func (d *curveSwap) Quote(opts *bind.CallOpts, pool *Pool, state *State, in, out int, amountIn *big.Int) (amountOut *big.Int, err error) {
+	poolContract, err := curve.NewICurvePoolCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolCaller fail:%v", err)
+		return
+	}
+
+	if pool.Meta.(*curvePool).underlying {
+		amountOut, err = poolContract.GetDyUnderlying(opts, big.NewInt(int64(in)), big.NewInt(int64(out)), amountIn)
+		if err != nil {
+			err = fmt.Errorf("get_dy_underlying fail:%w", err)
+		}
+		return
+	}
+
+	amountOut, err = poolContract.GetDy(opts, big.NewInt(int64(in)), big.NewInt(int64(out)), amountIn)
+	if err != nil {
+		err = fmt.Errorf("get_dy fail:%w", err)
+	}
+	return
+}
+
+// Subscribe reloads the state of pool on every exchange
+func (d *curveSwap) Subscribe(ctx context.Context, pool *Pool, sink chan<- *Update) (sub event.Subscription, err error) {
+	filterer, ok := d.backend.(bind.ContractFilterer)
+	if !ok {
+		err = ErrSubscribeNotSupported
+		return
+	}
+
+	poolFilterer, err := curve.NewICurvePoolFilterer(pool.Address, filterer)
+	if err != nil {
+		err = fmt.Errorf("NewICurvePoolFilterer fail:%v", err)
+		return
+	}
+
+	exchanges := make(chan *curve.ICurvePoolTokenExchange)
+	exchangeSub, err := poolFilterer.WatchTokenExchange(&bind.WatchOpts{Context: ctx}, exchanges, nil)
+	if err != nil {
+		err = fmt.Errorf("WatchTokenExchange fail:%w", err)
+		return
+	}
+	underlyings := make(chan *curve.ICurvePoolTokenExchangeUnderlying)
+	underlyingSub, err := poolFilterer.WatchTokenExchangeUnderlying(&bind.WatchOpts{Context: ctx}, underlyings, nil)
+	if err != nil {
+		exchangeSub.Unsubscribe()
+		err = fmt.Errorf("WatchTokenExchangeUnderlying fail:%w", err)
+		return
+	}
+
+	sub = event.NewSubscription(func(quit <-chan struct{}) error {
+		defer exchangeSub.Unsubscribe()
+		defer underlyingSub.Unsubscribe()
+		for {
+			var blockNumber uint64
+			select {
+			case exchange := <-exchanges:
+				blockNumber = exchange.Raw.BlockNumber
+			case exchange := <-underlyings:
+				blockNumber = exchange.Raw.BlockNumber
+			case err := <-exchangeSub.Err():
+				return err
+			case err := <-underlyingSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+
+			state, err := d.State(&bind.CallOpts{Context: ctx, BlockNumber: big.NewInt(0).SetUint64(blockNumber)}, pool)
+			if err != nil {
+				return err
+			}
+			select {
+			case sink <- &Update{Pool: pool, State: state, BlockNumber: blockNumber}:
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return
+}
+
+// stableSwapD computes the StableSwap invariant D of xp by Newton's method, as in the Curve contracts.
+// A drained coin has no invariant to price with and is ErrNoLiquidity.
+func stableSwapD(amp *big.Int, xp []*big.Int) (D *big.Int, err error) {
+	n := big.NewInt(int64(len(xp)))
+	S := big.NewInt(0)
+	for _, x := range xp {
+		if x.Sign() <= 0 {
+			err = ErrNoLiquidity
+			return
+		}
+		S.Add(S, x)
+	}
+
+	D = big.NewInt(0).Set(S)
+	Ann := big.NewInt(0).Mul(amp, n)
+	for i := 0; i < stableSwapIterations; i++ {
+		DP := big.NewInt(0).Set(D)
+		for _, x := range xp {
+			DP.Mul(DP, D)
+			DP.Div(DP, big.NewInt(0).Mul(x, n))
+		}
+		Dprev := D
+		// D = (Ann*S + DP*n) * D / ((Ann-1)*D + (n+1)*DP)
+		numerator := big.NewInt(0).Add(big.NewInt(0).Mul(Ann, S), big.NewInt(0).Mul(DP, n))
+		numerator.Mul(numerator, D)
+		denominator := big.NewInt(0).Mul(big.NewInt(0).Sub(Ann, big.NewInt(1)), D)
+		denominator.Add(denominator, big.NewInt(0).Mul(big.NewInt(0).Add(n, big.NewInt(1)), DP))
+		D = numerator.Div(numerator, denominator)
+		if big.NewInt(0).Sub(D, Dprev).CmpAbs(big.NewInt(1)) <= 0 {
+			return
+		}
+	}
+	err = fmt.Errorf("stableswap D did not converge")
+	return
+}
+
+// stableSwapY computes the balance of j that keeps D unchanged once the balance of i is x
+func stableSwapY(amp *big.Int, i, j int, x *big.Int, xp []*big.Int) (y *big.Int, err error) {
+	D, err := stableSwapD(amp, xp)
+	if err != nil {
+		return
+	}
+
+	n := big.NewInt(int64(len(xp)))
+	Ann := big.NewInt(0).Mul(amp, n)
+	c := big.NewInt(0).Set(D)
+	S := big.NewInt(0)
+	for k := range xp {
+		var xk *big.Int
+		switch k {
+		case i:
+			xk = x
+		case j:
+			continue
+		default:
+			xk = xp[k]
+		}
+		if xk.Sign() <= 0 {
+			err = ErrNoLiquidity
+			return
+		}
+		S.Add(S, xk)
+		c.Mul(c, D)
+		c.Div(c, big.NewInt(0).Mul(xk, n))
+	}
+	c.Mul(c, D)
+	c.Div(c, big.NewInt(0).Mul(Ann, n))
+	b := big.NewInt(0).Add(S, big.NewInt(0).Div(D, Ann))
+
+	y = big.NewInt(0).Set(D)
+	for k := 0; k < stableSwapIterations; k++ {
+		yPrev := y
+		// y = (y*y + c) / (2*y + b - D)
+		numerator := big.NewInt(0).Add(big.NewInt(0).Mul(y, y), c)
+		denominator := big.NewInt(0).Add(big.NewInt(0).Mul(big.NewInt(2), y), b)
+		denominator.Sub(denominator, D)
+		if denominator.Sign() <= 0 {
+			err = ErrNoLiquidity
+			return
+		}
+		y = numerator.Div(numerator, denominator)
+		if big.NewInt(0).Sub(y, yPrev).CmpAbs(big.NewInt(1)) <= 0 {
+			return
+		}
+	}
+	err = fmt.Errorf("stableswap y did not converge")
+	return
+}
+
+var _ Dex = (*curveSwap)(nil)
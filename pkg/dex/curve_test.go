@@ -0,0 +1,197 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/curve"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+)
+
+func bigInt(s string) *big.Int {
+	n, ok := big.NewInt(0).SetString(s, 10)
+	if !ok {
+		panic("invalid integer " + s)
+	}
+	return n
+}
+
+func bigInts(ss ...string) (ns []*big.Int) {
+	for _, s := range ss {
+		ns = append(ns, bigInt(s))
+	}
+	return
+}
+
+// the expected D and y below are get_D and get_y of the Curve 3pool contract, in integer math
+func TestStableSwapD(t *testing.T) {
+	cases := []struct {
+		name string
+		amp  int64
+		xp   []*big.Int
+		D    string
+	}{
+		{"balanced", 100, bigInts("1000000000000000000000000", "1000000000000000000000000", "1000000000000000000000000"), "3000000000000000000000000"},
+		{"three coins", 2000, bigInts("1000000000000000000000000", "500000000000000000000000", "250000000000000000000000"), "1749828663603667876718792"},
+		{"two coins", 100, bigInts("1000000000000000000000000", "3000000000000000000000000"), "3993431643088518257649019"},
+		{"low amp", 10, bigInts("5000000000000000000", "7000000000000000000"), "11984473261603296033"},
+	}
+	for _, c := range cases {
+		D, err := stableSwapD(big.NewInt(c.amp), c.xp)
+		if err != nil {
+			t.Fatalf("%s: stableSwapD fail:%v", c.name, err)
+		}
+		if D.Cmp(bigInt(c.D)) != 0 {
+			t.Fatalf("%s: D %s, want %s", c.name, D, c.D)
+		}
+	}
+}
+
+func TestStableSwapY(t *testing.T) {
+	cases := []struct {
+		name string
+		amp  int64
+		xp   []*big.Int
+		i, j int
+		x    string
+		y    string
+	}{
+		{"three coins", 2000, bigInts("1000000000000000000000000", "500000000000000000000000", "250000000000000000000000"), 0, 1, "1001000000000000000000000", "499000463934878169930643"},
+		{"two coins", 100, bigInts("1000000000000000000000000", "3000000000000000000000000"), 1, 0, "3010000000000000000000000", "990174285025754744362110"},
+		{"one wei", 100, bigInts("1000000000000000000000000", "1000000000000000000000000", "1000000000000000000000000"), 2, 0, "1000000000000000000000001", "999999999999999999999999"},
+	}
+	for _, c := range cases {
+		y, err := stableSwapY(big.NewInt(c.amp), c.i, c.j, bigInt(c.x), c.xp)
+		if err != nil {
+			t.Fatalf("%s: stableSwapY fail:%v", c.name, err)
+		}
+		if y.Cmp(bigInt(c.y)) != 0 {
+			t.Fatalf("%s: y %s, want %s", c.name, y, c.y)
+		}
+	}
+}
+
+func TestStableSwapDrainedCoin(t *testing.T) {
+	xp := bigInts("1000000000000000000000000", "1000000000000000000000000", "0")
+	if _, err := stableSwapD(big.NewInt(100), xp); !errors.Is(err, ErrNoLiquidity) {
+		t.Fatalf("stableSwapD err %v, want %v", err, ErrNoLiquidity)
+	}
+	if _, err := stableSwapY(big.NewInt(100), 0, 1, bigInt("1000000000000000000000001"), xp); !errors.Is(err, ErrNoLiquidity) {
+		t.Fatalf("stableSwapY err %v, want %v", err, ErrNoLiquidity)
+	}
+
+	// SpotPrice only checks the reserves of base and quote, the drained third coin must not panic
+	d := &curveSwap{}
+	pool := &Pool{Decimals: []uint8{18, 18, 18}, Meta: &curvePool{n: 3}}
+	balances := bigInts("1000000000000000000000000", "1000000000000000000000000", "0")
+	state := &State{Reserves: balances, Meta: &curveState{amp: big.NewInt(100), fee: big.NewInt(0), balances: balances}}
+	if _, err := d.SpotPrice(pool, state, 0, 1); !errors.Is(err, ErrNoLiquidity) {
+		t.Fatalf("SpotPrice err %v, want %v", err, ErrNoLiquidity)
+	}
+}
+
+// rpcError is an error answered by a node
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcError) Error() string  { return e.msg }
+func (e *rpcError) ErrorCode() int { return e.code }
+
+// fakeCurvePool is a bind.ContractCaller of a curve pool whose coins are 18 decimals tokens
+type fakeCurvePool struct {
+	coins []common.Address
+	// endErr answers coins(len(coins))
+	endErr error
+	// baseErr answers base_pool if set
+	baseErr error
+}
+
+var fakeCurveMethods = make(map[[4]byte]abi.Method)
+
+func init() {
+	for _, abiJSON := range []string{curve.ICurvePoolABI, erc20.IERC20ABI} {
+		parsed, err := abi.JSON(strings.NewReader(abiJSON))
+		if err != nil {
+			panic(fmt.Sprintf("abi.JSON fail:%v", err))
+		}
+		for _, method := range parsed.Methods {
+			var selector [4]byte
+			copy(selector[:], method.ID)
+			fakeCurveMethods[selector] = method
+		}
+	}
+}
+
+func (f *fakeCurvePool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (f *fakeCurvePool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var selector [4]byte
+	copy(selector[:], call.Data)
+	method := fakeCurveMethods[selector]
+	switch method.RawName {
+	case "coins":
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		i := int(args[0].(*big.Int).Int64())
+		if i >= len(f.coins) {
+			return nil, f.endErr
+		}
+		return method.Outputs.Pack(f.coins[i])
+	case "base_pool":
+		if f.baseErr != nil {
+			return nil, f.baseErr
+		}
+		return method.Outputs.Pack(common.Address{})
+	case "decimals":
+		return method.Outputs.Pack(uint8(18))
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func TestCurveLoadPoolErrors(t *testing.T) {
+	coins := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}
+	revert := &rpcError{code: 3, msg: "execution reverted"}
+	timeout := errors.New("Post \"http://node\": context deadline exceeded")
+	cases := []struct {
+		name    string
+		backend *fakeCurvePool
+		// n is the number of coins loaded, 0 if LoadPool fails
+		n int
+	}{
+		{"geth revert", &fakeCurvePool{coins: coins, endErr: revert, baseErr: revert}, 3},
+		{"openethereum revert", &fakeCurvePool{coins: coins, endErr: &rpcError{code: -32015, msg: "VM execution error."}, baseErr: revert}, 3},
+		{"coins timeout", &fakeCurvePool{coins: coins[:2], endErr: timeout, baseErr: revert}, 0},
+		{"coins node error", &fakeCurvePool{coins: coins[:2], endErr: &rpcError{code: 429, msg: "too many requests"}, baseErr: revert}, 0},
+		{"base_pool timeout", &fakeCurvePool{coins: coins, endErr: revert, baseErr: timeout}, 0},
+	}
+	for _, c := range cases {
+		d := &curveSwap{backend: c.backend}
+		pool, err := d.LoadPool(&bind.CallOpts{Context: context.Background()}, common.HexToAddress("0xc0"))
+		if c.n == 0 {
+			if err == nil {
+				t.Fatalf("%s: LoadPool loaded %d coins, want an error", c.name, len(pool.Tokens))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadPool fail:%v", c.name, err)
+		}
+		if len(pool.Tokens) != c.n {
+			t.Fatalf("%s: %d coins, want %d", c.name, len(pool.Tokens), c.n)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+// swap types
+const (
+	TypeUniswapV2 = "uniswapv2"
+	TypeCurve     = "curve"
+	TypeBalancer  = "balancer"
+	TypeSolidly   = "solidly"
+)
+
+var (
+	// ErrNoPool is returned by ResolvePool when the pair has no pool
+	ErrNoPool = errors.New("pool not exists")
+	// ErrNoLiquidity is returned when a pool is empty
+	ErrNoLiquidity = errors.New("pool has no liquidity")
+	// ErrSubscribeNotSupported is returned by Subscribe when the backend can not filter logs
+	ErrSubscribeNotSupported = errors.New("backend does not support subscriptions")
+)
+
+// Pool is the metadata of a liquidity pool
+type Pool struct {
+	Address common.Address
+	// Tokens in pool order
+	Tokens   []common.Address
+	Decimals []uint8
+	// Meta holds adapter specific metadata
+	Meta interface{}
+}
+
+// Index returns the index of token in the pool, -1 if not found
+func (p *Pool) Index(token common.Address) int {
+	for i, t := range p.Tokens {
+		if t == token {
+			return i
+		}
+	}
+	return -1
+}
+
+// State is a snapshot of the balances of a pool
+type State struct {
+	// Reserves in Tokens order
+	Reserves []*big.Int
+	// Timestamp of the last reserve update reported by the pool, 0 if unknown
+	Timestamp uint32
+	// Meta holds adapter specific state
+	Meta interface{}
+}
+
+// Update is sent to subscribers whenever a pool changes
+type Update struct {
+	Pool        *Pool
+	State       *State
+	BlockNumber uint64
+}
+
+// Dex is implemented by every supported AMM.
+// Token arguments are indexes into Pool.Tokens.
+type Dex interface {
+	// ResolvePool finds the pool trading the tokens of pair
+	ResolvePool(opts *bind.CallOpts, pair *config.Pair) (*Pool, error)
+	// LoadPool loads the metadata of the pool at addr
+	LoadPool(opts *bind.CallOpts, addr common.Address) (*Pool, error)
+	// State reads the balances of pool
+	State(opts *bind.CallOpts, pool *Pool) (*State, error)
+	// SpotPrice is the decimal adjusted marginal price of base in quote units
+	SpotPrice(pool *Pool, state *State, base, quote int) (float64, error)
+	// Quote is the raw amount of out received for swapping amountIn of in
+	Quote(opts *bind.CallOpts, pool *Pool, state *State, in, out int, amountIn *big.Int) (*big.Int, error)
+	// Subscribe sends an Update to sink whenever pool changes
+	Subscribe(ctx context.Context, pool *Pool, sink chan<- *Update) (event.Subscription, error)
+}
+
+// ConstantProduct is implemented by dexes whose pools follow x*y=k
+type ConstantProduct interface {
+	// Fee is the swap fee of pool as a fraction of the input
+	Fee(pool *Pool) float64
+}
+
+// Oracle is implemented by dexes with Uniswap V2 style price accumulators
+type Oracle interface {
+	// CumulativePrice is the UQ112x112 cumulative raw price of base in quote, extrapolated to now
+	CumulativePrice(opts *bind.CallOpts, pool *Pool, state *State, base int, now int64) (*big.Int, error)
+}
+
+// LPPool is implemented by dexes whose pools are their own LP token
+type LPPool interface {
+	TotalSupply(opts *bind.CallOpts, pool *Pool) (*big.Int, error)
+	// KLast is the invariant as of the most recent liquidity event, nil if not tracked
+	KLast(opts *bind.CallOpts, pool *Pool) (*big.Int, error)
+}
+
+// New creates the Dex selected by swap.Type, Uniswap V2 if not set
+func New(swap *config.Swap, backend bind.ContractCaller) (Dex, error) {
+	switch swap.Type {
+	case "", TypeUniswapV2:
+		d, err := newUniswapV2(swap, backend)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case TypeCurve:
+		d, err := newCurve(swap, backend)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case TypeBalancer:
+		d, err := newBalancer(swap, backend)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case TypeSolidly:
+		d, err := newSolidly(swap, backend)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unknown swap type %s for %s", swap.Type, swap.Name)
+	}
+}
+
+// PoolAddress is the address of the pool of pair on swap if it is known without calls,
+// the Pair.Pool of curve and balancer or the CREATE2 address of a uniswapv2 swap with an InitCodeHash
+func PoolAddress(swap *config.Swap, pair *config.Pair) (addr common.Address, ok bool) {
+	switch swap.Type {
+	case "", TypeUniswapV2:
+		if swap.InitCodeHash == "" {
+			return
+		}
+		addr = v2PairFor(common.HexToAddress(swap.Factory), common.HexToHash(swap.InitCodeHash),
+			common.HexToAddress(pair.TargetTokenAddr), common.HexToAddress(pair.PriceTokenAddr))
+		ok = true
+	case TypeCurve, TypeBalancer:
+		if common.IsHexAddress(pair.Pool) {
+			addr, ok = common.HexToAddress(pair.Pool), true
+		}
+	}
+	return
+}
+
+// DecimalAdjust converts a raw token amount to whole token units
+func DecimalAdjust(amount *big.Int, decimals uint8) float64 {
+	f, _ := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(amount), Pow10(decimals)).Float64()
+	return f
+}
+
+// Pow10 returns 10^decimals
+func Pow10(decimals uint8) *big.Float {
+	return big.NewFloat(0).SetInt(big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+// isRevert tells a call the contract reverted from a call that failed to reach it, e.g. a timeout or a node error.
+// Geth answers reverts with code 3 or "execution reverted", OpenEthereum with code -32015.
+func isRevert(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case 3, -32015:
+			return true
+		}
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
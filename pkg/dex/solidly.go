@@ -0,0 +1,306 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/solidly"
+)
+
+const (
+	// solidlyFeeBps is the swap fee of Solidly pairs in basis points, forks configure their own with Swap.FeeBps
+	solidlyFeeBps = 1
+	// solidlyIterations bounds the Newton iterations of _get_y
+	solidlyIterations = 255
+)
+
+var solidlyOne = big.NewInt(1e18)
+
+// solidlyPool is the Pool.Meta of a Solidly pair
+type solidlyPool struct {
+	// stable pairs follow x³y+y³x=k, volatile ones xy=k
+	stable bool
+}
+
+// solidlySwap prices Solidly style factories (Velodrome, Aerodrome, ...) creating
+// both volatile and stable pairs for the same tokens, selected by Pair.Stable
+type solidlySwap struct {
+	swap    *config.Swap
+	backend bind.ContractCaller
+	factory *solidly.ISolidlyFactoryCaller
+	feeBps  int64
+}
+
+func newSolidly(swap *config.Swap, backend bind.ContractCaller) (d *solidlySwap, err error) {
+	factory, err := solidly.NewISolidlyFactoryCaller(common.HexToAddress(swap.Factory), backend)
+	if err != nil {
+		err = fmt.Errorf("NewISolidlyFactoryCaller fail:%v", err)
+		return
+	}
+
+	d = &solidlySwap{swap: swap, backend: backend, factory: factory, feeBps: solidlyFeeBps}
+	if swap.FeeBps != 0 {
+		if swap.FeeBps < 0 || swap.FeeBps >= 10000 {
+			err = fmt.Errorf("invalid FeeBps %d for %s", swap.FeeBps, swap.Name)
+			return
+		}
+		d.feeBps = swap.FeeBps
+	}
+	return
+}
+
+func (d *solidlySwap) ResolvePool(opts *bind.CallOpts, pair *config.Pair) (pool *Pool, err error) {
+	targetTokenAddr := common.HexToAddress(pair.TargetTokenAddr)
+	priceTokenAddr := common.HexToAddress(pair.PriceTokenAddr)
+	pairAddr, err := d.factory.GetPair(opts, targetTokenAddr, priceTokenAddr, pair.Stable)
+	if err != nil {
+		err = fmt.Errorf("GetPair fail:%w", err)
+		return
+	}
+
+	if pairAddr == (common.Address{}) {
+		err = fmt.Errorf("pair(%s/%s stable:%v) %w", pair.TargetTokenName, pair.PriceTokenName, pair.Stable, ErrNoPool)
+		return
+	}
+
+	pool, err = d.LoadPool(opts, pairAddr)
+	if err != nil {
+		return
+	}
+
+	if pool.Index(targetTokenAddr) < 0 || pool.Index(priceTokenAddr) < 0 {
+		err = fmt.Errorf("invalid pair for %s", pair.TargetTokenName)
+		pool = nil
+		return
+	}
+	return
+}
+
+func (d *solidlySwap) LoadPool(opts *bind.CallOpts, addr common.Address) (pool *Pool, err error) {
+	pairContract, err := solidly.NewISolidlyPairCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewISolidlyPairCaller fail:%v", err)
+		return
+	}
+
+	token0Addr, err := pairContract.Token0(opts)
+	if err != nil {
+		err = fmt.Errorf("Token0 fail:%w", err)
+		return
+	}
+	token1Addr, err := pairContract.Token1(opts)
+	if err != nil {
+		err = fmt.Errorf("Token1 fail:%w", err)
+		return
+	}
+	stable, err := pairContract.Stable(opts)
+	if err != nil {
+		err = fmt.Errorf("Stable fail:%w", err)
+		return
+	}
+
+	pool = &Pool{Address: addr, Tokens: []common.Address{token0Addr, token1Addr}, Meta: &solidlyPool{stable: stable}}
+	pool.Decimals, err = loadDecimals(opts, d.backend, pool.Tokens)
+	if err != nil {
+		pool = nil
+	}
+	return
+}
+
+func (d *solidlySwap) State(opts *bind.CallOpts, pool *Pool) (state *State, err error) {
+	pairContract, err := solidly.NewISolidlyPairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewISolidlyPairCaller fail:%v", err)
+		return
+	}
+
+	r, err := pairContract.GetReserves(opts)
+	if err != nil {
+		err = fmt.Errorf("GetReserves fail:%w", err)
+		return
+	}
+
+	state = &State{Reserves: []*big.Int{r.Reserve0, r.Reserve1}, Timestamp: uint32(r.BlockTimestampLast.Uint64())}
+	return
+}
+
+// SpotPrice is the reserve ratio for volatile pairs, and the slope of
+// x³y+y³x=k for stable pairs: (3x²y+y³)/(x³+3xy²)
+func (d *solidlySwap) SpotPrice(pool *Pool, state *State, base, quote int) (price float64, err error) {
+	if state.Reserves[base].Sign() == 0 || state.Reserves[quote].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	x := DecimalAdjust(state.Reserves[base], pool.Decimals[base])
+	y := DecimalAdjust(state.Reserves[quote], pool.Decimals[quote])
+	if !pool.Meta.(*solidlyPool).stable {
+		price = y / x
+		return
+	}
+
+	price = (3*x*x*y + y*y*y) / (x*x*x + 3*x*y*y)
+	return
+}
+
+// Quote implements Pair._getAmountOut
+func (d *solidlySwap) Quote(opts *bind.CallOpts, pool *Pool, state *State, in, out int, amountIn *big.Int) (amountOut *big.Int, err error) {
+	if state.Reserves[in].Sign() == 0 || state.Reserves[out].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	amountIn = big.NewInt(0).Sub(amountIn, big.NewInt(0).Div(big.NewInt(0).Mul(amountIn, big.NewInt(d.feeBps)), big.NewInt(10000)))
+	if !pool.Meta.(*solidlyPool).stable {
+		numerator := big.NewInt(0).Mul(amountIn, state.Reserves[out])
+		denominator := big.NewInt(0).Add(state.Reserves[in], amountIn)
+		amountOut = numerator.Div(numerator, denominator)
+		return
+	}
+
+	// normalize to 18 decimals as the pair does
+	unitIn := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(pool.Decimals[in])), nil)
+	unitOut := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(pool.Decimals[out])), nil)
+	normalize := func(amount, unit *big.Int) *big.Int {
+		return big.NewInt(0).Div(big.NewInt(0).Mul(amount, solidlyOne), unit)
+	}
+	reserveIn := normalize(state.Reserves[in], unitIn)
+	reserveOut := normalize(state.Reserves[out], unitOut)
+
+	xy := solidlyK(reserveIn, reserveOut)
+	y, err := solidlyY(big.NewInt(0).Add(normalize(amountIn, unitIn), reserveIn), xy, reserveOut)
+	if err != nil {
+		return
+	}
+	amountOut = big.NewInt(0).Sub(reserveOut, y)
+	amountOut.Mul(amountOut, unitOut)
+	amountOut.Div(amountOut, solidlyOne)
+	return
+}
+
+func (d *solidlySwap) Subscribe(ctx context.Context, pool *Pool, sink chan<- *Update) (sub event.Subscription, err error) {
+	filterer, ok := d.backend.(bind.ContractFilterer)
+	if !ok {
+		err = ErrSubscribeNotSupported
+		return
+	}
+
+	pairFilterer, err := solidly.NewISolidlyPairFilterer(pool.Address, filterer)
+	if err != nil {
+		err = fmt.Errorf("NewISolidlyPairFilterer fail:%v", err)
+		return
+	}
+
+	syncs := make(chan *solidly.ISolidlyPairSync)
+	syncSub, err := pairFilterer.WatchSync(&bind.WatchOpts{Context: ctx}, syncs)
+	if err != nil {
+		err = fmt.Errorf("WatchSync fail:%w", err)
+		return
+	}
+
+	sub = event.NewSubscription(func(quit <-chan struct{}) error {
+		defer syncSub.Unsubscribe()
+		for {
+			select {
+			case sync := <-syncs:
+				update := &Update{
+					Pool:        pool,
+					State:       &State{Reserves: []*big.Int{sync.Reserve0, sync.Reserve1}},
+					BlockNumber: sync.Raw.BlockNumber,
+				}
+				select {
+				case sink <- update:
+				case <-quit:
+					return nil
+				}
+			case err := <-syncSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return
+}
+
+func (d *solidlySwap) TotalSupply(opts *bind.CallOpts, pool *Pool) (totalSupply *big.Int, err error) {
+	pairContract, err := solidly.NewISolidlyPairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewISolidlyPairCaller fail:%v", err)
+		return
+	}
+
+	totalSupply, err = pairContract.TotalSupply(opts)
+	if err != nil {
+		err = fmt.Errorf("TotalSupply fail:%w", err)
+	}
+	return
+}
+
+// KLast is not tracked by Solidly pairs
+func (d *solidlySwap) KLast(opts *bind.CallOpts, pool *Pool) (kLast *big.Int, err error) {
+	return
+}
+
+// solidlyK is the stable invariant x³y+y³x of 18 decimals reserves, as Pair._k computes it
+func solidlyK(x, y *big.Int) *big.Int {
+	a := big.NewInt(0).Div(big.NewInt(0).Mul(x, y), solidlyOne)
+	b := big.NewInt(0).Div(big.NewInt(0).Mul(x, x), solidlyOne)
+	b.Add(b, big.NewInt(0).Div(big.NewInt(0).Mul(y, y), solidlyOne))
+	return a.Div(a.Mul(a, b), solidlyOne)
+}
+
+// solidlyY solves x0³y+y³x0=xy for y by Newton's method, starting from y, as Pair._get_y
+func solidlyY(x0, xy, y *big.Int) (*big.Int, error) {
+	y = big.NewInt(0).Set(y)
+	for i := 0; i < solidlyIterations; i++ {
+		k := solidlyF(x0, y)
+		d := solidlyD(x0, y)
+		if d.Sign() == 0 {
+			break
+		}
+		var dy *big.Int
+		if k.Cmp(xy) < 0 {
+			dy = big.NewInt(0).Sub(xy, k)
+			dy.Div(dy.Mul(dy, solidlyOne), d)
+			y.Add(y, dy)
+		} else {
+			dy = big.NewInt(0).Sub(k, xy)
+			dy.Div(dy.Mul(dy, solidlyOne), d)
+			y.Sub(y, dy)
+		}
+		if dy.Cmp(big.NewInt(1)) <= 0 {
+			return y, nil
+		}
+	}
+	return nil, fmt.Errorf("solidly y did not converge")
+}
+
+// solidlyF is x0*y³+x0³*y in 18 decimals
+func solidlyF(x0, y *big.Int) *big.Int {
+	y3 := big.NewInt(0).Div(big.NewInt(0).Mul(y, y), solidlyOne)
+	y3.Div(y3.Mul(y3, y), solidlyOne)
+	x3 := big.NewInt(0).Div(big.NewInt(0).Mul(x0, x0), solidlyOne)
+	x3.Div(x3.Mul(x3, x0), solidlyOne)
+	f := big.NewInt(0).Div(big.NewInt(0).Mul(x0, y3), solidlyOne)
+	return f.Add(f, big.NewInt(0).Div(big.NewInt(0).Mul(x3, y), solidlyOne))
+}
+
+// solidlyD is the derivative of solidlyF in y, 3*x0*y²+x0³
+func solidlyD(x0, y *big.Int) *big.Int {
+	y2 := big.NewInt(0).Div(big.NewInt(0).Mul(y, y), solidlyOne)
+	d := big.NewInt(0).Div(big.NewInt(0).Mul(big.NewInt(0).Mul(big.NewInt(3), x0), y2), solidlyOne)
+	x3 := big.NewInt(0).Div(big.NewInt(0).Mul(x0, x0), solidlyOne)
+	x3.Div(x3.Mul(x3, x0), solidlyOne)
+	return d.Add(d, x3)
+}
+
+var (
+	_ Dex    = (*solidlySwap)(nil)
+	_ LPPool = (*solidlySwap)(nil)
+)
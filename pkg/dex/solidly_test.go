@@ -0,0 +1,34 @@
+package dex
+
+import "testing"
+
+// the expected k and y below are _k and _get_y of the Solidly Pair contract, in integer math
+func TestSolidlyY(t *testing.T) {
+	cases := []struct {
+		name       string
+		reserveIn  string
+		reserveOut string
+		amountIn   string
+		k          string
+		y          string
+	}{
+		{"balanced", "1000000000000000000000000", "1000000000000000000000000", "1000000000000000000000", "2000000000000000000000000000000000000000000", "999000000000499999999500"},
+		{"skewed", "1000000000000000000000000", "2000000000000000000000000", "5000000000000000000000", "10000000000000000000000000000000000000000000", "1994621481592047163236355"},
+		{"large trade", "500000000000000000000000", "1500000000000000000000000", "100000000000000000000000", "1875000000000000000000000000000000000000000", "1380021075900847847676648"},
+		{"one wei", "100000000000000000000", "100000000000000000000", "1", "200000000000000000000000000", "100000000000000000000"},
+	}
+	for _, c := range cases {
+		reserveIn, reserveOut := bigInt(c.reserveIn), bigInt(c.reserveOut)
+		k := solidlyK(reserveIn, reserveOut)
+		if k.Cmp(bigInt(c.k)) != 0 {
+			t.Fatalf("%s: k %s, want %s", c.name, k, c.k)
+		}
+		y, err := solidlyY(reserveIn.Add(reserveIn, bigInt(c.amountIn)), k, reserveOut)
+		if err != nil {
+			t.Fatalf("%s: solidlyY fail:%v", c.name, err)
+		}
+		if y.Cmp(bigInt(c.y)) != 0 {
+			t.Fatalf("%s: y %s, want %s", c.name, y, c.y)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/uni"
+)
+
+// v2FeeBps is the swap fee of Uniswap V2 in basis points, forks configure their own with Swap.FeeBps
+const v2FeeBps = 30
+
+var (
+	q112    = big.NewInt(0).Lsh(big.NewInt(1), 112)
+	uint256 = big.NewInt(0).Lsh(big.NewInt(1), 256)
+)
+
+// uniswapV2 prices IUniswapV2Pair pools created by an IUniswapV2Factory
+type uniswapV2 struct {
+	swap    *config.Swap
+	backend bind.ContractCaller
+	factory *uni.IUniswapV2FactoryCaller
+	feeBps  int64
+	// initCodeHash of the pair contract, zero if pairs are resolved with GetPair
+	initCodeHash common.Hash
+}
+
+func newUniswapV2(swap *config.Swap, backend bind.ContractCaller) (d *uniswapV2, err error) {
+	factory, err := uni.NewIUniswapV2FactoryCaller(common.HexToAddress(swap.Factory), backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2FactoryCaller fail:%v", err)
+		return
+	}
+
+	d = &uniswapV2{swap: swap, backend: backend, factory: factory, feeBps: v2FeeBps}
+	if swap.FeeBps != 0 {
+		if swap.FeeBps < 0 || swap.FeeBps >= 10000 {
+			err = fmt.Errorf("invalid FeeBps %d for %s", swap.FeeBps, swap.Name)
+			return
+		}
+		d.feeBps = swap.FeeBps
+	}
+	if swap.InitCodeHash != "" {
+		d.initCodeHash = common.HexToHash(swap.InitCodeHash)
+	}
+	return
+}
+
+// pairFor computes the CREATE2 address of the a/b pair, as UniswapV2Library.pairFor
+func (d *uniswapV2) pairFor(a, b common.Address) common.Address {
+	return v2PairFor(common.HexToAddress(d.swap.Factory), d.initCodeHash, a, b)
+}
+
+func v2PairFor(factory common.Address, initCodeHash common.Hash, a, b common.Address) common.Address {
+	if bytes.Compare(a.Bytes(), b.Bytes()) > 0 {
+		a, b = b, a
+	}
+	salt := crypto.Keccak256Hash(a.Bytes(), b.Bytes())
+	return crypto.CreateAddress2(factory, salt, initCodeHash.Bytes())
+}
+
+func (d *uniswapV2) ResolvePool(opts *bind.CallOpts, pair *config.Pair) (pool *Pool, err error) {
+	targetTokenAddr := common.HexToAddress(pair.TargetTokenAddr)
+	priceTokenAddr := common.HexToAddress(pair.PriceTokenAddr)
+	if d.initCodeHash != (common.Hash{}) {
+		// the pair address is known offline, a missing pair shows up as ErrNoPool in State
+		pool = &Pool{Address: d.pairFor(targetTokenAddr, priceTokenAddr)}
+		pool.Tokens = []common.Address{targetTokenAddr, priceTokenAddr}
+		if bytes.Compare(targetTokenAddr.Bytes(), priceTokenAddr.Bytes()) > 0 {
+			pool.Tokens[0], pool.Tokens[1] = priceTokenAddr, targetTokenAddr
+		}
+		pool.Decimals, err = loadDecimals(opts, d.backend, pool.Tokens)
+		if err != nil {
+			pool = nil
+		}
+		return
+	}
+
+	pairAddr, err := d.factory.GetPair(opts, targetTokenAddr, priceTokenAddr)
+	if err != nil {
+		err = fmt.Errorf("GetPair fail:%w", err)
+		return
+	}
+
+	if pairAddr == (common.Address{}) {
+		err = fmt.Errorf("pair(%s/%s) %w", pair.TargetTokenName, pair.PriceTokenName, ErrNoPool)
+		return
+	}
+
+	pool, err = d.LoadPool(opts, pairAddr)
+	if err != nil {
+		return
+	}
+
+	if pool.Index(targetTokenAddr) < 0 || pool.Index(priceTokenAddr) < 0 {
+		err = fmt.Errorf("invalid pair for %s", pair.TargetTokenName)
+		pool = nil
+		return
+	}
+	return
+}
+
+func (d *uniswapV2) LoadPool(opts *bind.CallOpts, addr common.Address) (pool *Pool, err error) {
+	pairContract, err := uni.NewIUniswapV2PairCaller(addr, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairCaller fail:%v", err)
+		return
+	}
+
+	token0Addr, err := pairContract.Token0(opts)
+	if err != nil {
+		err = fmt.Errorf("Token0 fail:%w", err)
+		return
+	}
+	token1Addr, err := pairContract.Token1(opts)
+	if err != nil {
+		err = fmt.Errorf("Token1 fail:%w", err)
+		return
+	}
+
+	pool = &Pool{Address: addr, Tokens: []common.Address{token0Addr, token1Addr}}
+	pool.Decimals, err = loadDecimals(opts, d.backend, pool.Tokens)
+	if err != nil {
+		pool = nil
+	}
+	return
+}
+
+func (d *uniswapV2) State(opts *bind.CallOpts, pool *Pool) (state *State, err error) {
+	pairContract, err := uni.NewIUniswapV2PairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairCaller fail:%v", err)
+		return
+	}
+
+	r, err := pairContract.GetReserves(opts)
+	if err != nil {
+		if errors.Is(err, bind.ErrNoCode) {
+			err = fmt.Errorf("pair %s %w", pool.Address.Hex(), ErrNoPool)
+			return
+		}
+		err = fmt.Errorf("GetReserves fail:%w", err)
+		return
+	}
+
+	state = &State{Reserves: []*big.Int{r.Reserve0, r.Reserve1}, Timestamp: r.BlockTimestampLast}
+	return
+}
+
+func (d *uniswapV2) SpotPrice(pool *Pool, state *State, base, quote int) (price float64, err error) {
+	if state.Reserves[base].Sign() == 0 || state.Reserves[quote].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	price = DecimalAdjust(state.Reserves[quote], pool.Decimals[quote]) / DecimalAdjust(state.Reserves[base], pool.Decimals[base])
+	return
+}
+
+// Quote implements UniswapV2Library.getAmountOut
+func (d *uniswapV2) Quote(opts *bind.CallOpts, pool *Pool, state *State, in, out int, amountIn *big.Int) (amountOut *big.Int, err error) {
+	if state.Reserves[in].Sign() == 0 || state.Reserves[out].Sign() == 0 {
+		err = ErrNoLiquidity
+		return
+	}
+
+	amountInWithFee := big.NewInt(0).Mul(amountIn, big.NewInt(10000-d.feeBps))
+	numerator := big.NewInt(0).Mul(amountInWithFee, state.Reserves[out])
+	denominator := big.NewInt(0).Add(big.NewInt(0).Mul(state.Reserves[in], big.NewInt(10000)), amountInWithFee)
+	amountOut = numerator.Div(numerator, denominator)
+	return
+}
+
+func (d *uniswapV2) Subscribe(ctx context.Context, pool *Pool, sink chan<- *Update) (sub event.Subscription, err error) {
+	filterer, ok := d.backend.(bind.ContractFilterer)
+	if !ok {
+		err = ErrSubscribeNotSupported
+		return
+	}
+
+	pairFilterer, err := uni.NewIUniswapV2PairFilterer(pool.Address, filterer)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairFilterer fail:%v", err)
+		return
+	}
+
+	syncs := make(chan *uni.IUniswapV2PairSync)
+	syncSub, err := pairFilterer.WatchSync(&bind.WatchOpts{Context: ctx}, syncs)
+	if err != nil {
+		err = fmt.Errorf("WatchSync fail:%w", err)
+		return
+	}
+
+	sub = event.NewSubscription(func(quit <-chan struct{}) error {
+		defer syncSub.Unsubscribe()
+		for {
+			select {
+			case sync := <-syncs:
+				update := &Update{
+					Pool:        pool,
+					State:       &State{Reserves: []*big.Int{sync.Reserve0, sync.Reserve1}},
+					BlockNumber: sync.Raw.BlockNumber,
+				}
+				select {
+				case sink <- update:
+				case <-quit:
+					return nil
+				}
+			case err := <-syncSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return
+}
+
+func (d *uniswapV2) Fee(pool *Pool) float64 {
+	return float64(d.feeBps) / 10000
+}
+
+func (d *uniswapV2) CumulativePrice(opts *bind.CallOpts, pool *Pool, state *State, base int, now int64) (cumulative *big.Int, err error) {
+	pairContract, err := uni.NewIUniswapV2PairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairCaller fail:%v", err)
+		return
+	}
+
+	if base == 0 {
+		cumulative, err = pairContract.Price0CumulativeLast(opts)
+	} else {
+		cumulative, err = pairContract.Price1CumulativeLast(opts)
+	}
+	if err != nil {
+		err = fmt.Errorf("PriceCumulativeLast fail:%w", err)
+		return
+	}
+
+	// counterfactual accumulation since the last update, as UniswapV2Pair._update would compute it
+	quote := 1 - base
+	spot := big.NewInt(0).Div(big.NewInt(0).Mul(state.Reserves[quote], q112), state.Reserves[base])
+	elapsed := uint32(now) - state.Timestamp
+	cumulative = big.NewInt(0).Add(cumulative, big.NewInt(0).Mul(spot, big.NewInt(int64(elapsed))))
+	cumulative.Mod(cumulative, uint256)
+	return
+}
+
+func (d *uniswapV2) TotalSupply(opts *bind.CallOpts, pool *Pool) (totalSupply *big.Int, err error) {
+	pairContract, err := uni.NewIUniswapV2PairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairCaller fail:%v", err)
+		return
+	}
+
+	totalSupply, err = pairContract.TotalSupply(opts)
+	if err != nil {
+		err = fmt.Errorf("TotalSupply fail:%w", err)
+	}
+	return
+}
+
+func (d *uniswapV2) KLast(opts *bind.CallOpts, pool *Pool) (kLast *big.Int, err error) {
+	pairContract, err := uni.NewIUniswapV2PairCaller(pool.Address, d.backend)
+	if err != nil {
+		err = fmt.Errorf("NewIUniswapV2PairCaller fail:%v", err)
+		return
+	}
+
+	kLast, err = pairContract.KLast(opts)
+	if err != nil {
+		err = fmt.Errorf("KLast fail:%w", err)
+	}
+	return
+}
+
+func loadDecimals(opts *bind.CallOpts, backend bind.ContractCaller, tokens []common.Address) (decimals []uint8, err error) {
+	for _, token := range tokens {
+		var tokenContract *erc20.IERC20Caller
+		tokenContract, err = erc20.NewIERC20Caller(token, backend)
+		if err != nil {
+			err = fmt.Errorf("NewIERC20Caller fail:%v", err)
+			return
+		}
+		var d uint8
+		d, err = tokenContract.Decimals(opts)
+		if err != nil {
+			err = fmt.Errorf("%s Decimals fail:%w", token.Hex(), err)
+			return
+		}
+		decimals = append(decimals, d)
+	}
+	return
+}
+
+var (
+	_ Dex             = (*uniswapV2)(nil)
+	_ ConstantProduct = (*uniswapV2)(nil)
+	_ Oracle          = (*uniswapV2)(nil)
+	_ LPPool          = (*uniswapV2)(nil)
+)
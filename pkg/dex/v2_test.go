@@ -0,0 +1,40 @@
+package dex
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// the expected pairs below are deployed on ethereum mainnet
+func TestV2PairFor(t *testing.T) {
+	const (
+		uniFactory  = "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"
+		uniInitHash = "0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
+		weth        = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		usdt        = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+		usdc        = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	)
+	cases := []struct {
+		name     string
+		factory  string
+		initHash string
+		a, b     string
+		pair     string
+	}{
+		{"uni weth/usdt", uniFactory, uniInitHash, weth, usdt, "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"},
+		{"uni usdc/weth", uniFactory, uniInitHash, usdc, weth, "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"},
+	}
+	for _, c := range cases {
+		factory, initHash := common.HexToAddress(c.factory), common.HexToHash(c.initHash)
+		a, b := common.HexToAddress(c.a), common.HexToAddress(c.b)
+		want := common.HexToAddress(c.pair)
+		if pair := v2PairFor(factory, initHash, a, b); pair != want {
+			t.Fatalf("%s: pair %s, want %s", c.name, pair.Hex(), want.Hex())
+		}
+		// the tokens are sorted, either order gives the same pair
+		if pair := v2PairFor(factory, initHash, b, a); pair != want {
+			t.Fatalf("%s reversed: pair %s, want %s", c.name, pair.Hex(), want.Hex())
+		}
+	}
+}
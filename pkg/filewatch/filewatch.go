@@ -0,0 +1,53 @@
+// Package filewatch reloads config files whenever they are modified
+package filewatch
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Watcher calls load on the first Reload and whenever any of files was modified since the last successful load.
+// A failed load changes nothing, so the caller keeps serving the last good value and the next Reload retries it.
+// It is not safe for concurrent use, callers guard it along with the value load sets.
+type Watcher struct {
+	files []string
+	load  func() error
+
+	loaded   bool
+	modTimes []time.Time
+}
+
+// New returns a Watcher of files, without any files load is only called once
+func New(load func() error, files ...string) *Watcher {
+	return &Watcher{files: files, load: load}
+}
+
+// Reload calls load if any file was modified since the last successful load
+func (w *Watcher) Reload() (err error) {
+	modTimes := make([]time.Time, len(w.files))
+	modified := !w.loaded
+	for i, file := range w.files {
+		var info os.FileInfo
+		info, err = os.Stat(file)
+		if err != nil {
+			err = fmt.Errorf("Stat fail:%v", err)
+			return
+		}
+		modTimes[i] = info.ModTime()
+		if !modified && !modTimes[i].Equal(w.modTimes[i]) {
+			modified = true
+		}
+	}
+	if !modified {
+		return
+	}
+
+	err = w.load()
+	if err != nil {
+		return
+	}
+	w.loaded = true
+	w.modTimes = modTimes
+	return
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+// log formats
+const (
+	FormatLogfmt   = "logfmt"
+	FormatJSON     = "json"
+	FormatTerminal = "terminal"
+)
+
+// Setup configures the root logger of go-ethereum/log, which the whole service logs to.
+// Without conf it logs at info level in logfmt.
+func Setup(conf *config.Log) (err error) {
+	level, format := "info", FormatLogfmt
+	if conf != nil {
+		if conf.Level != "" {
+			level = conf.Level
+		}
+		if conf.Format != "" {
+			format = conf.Format
+		}
+	}
+
+	lvl, err := log.LvlFromString(level)
+	if err != nil {
+		err = fmt.Errorf("invalid log level %s:%v", level, err)
+		return
+	}
+
+	var fmtr log.Format
+	switch format {
+	case FormatLogfmt:
+		fmtr = log.LogfmtFormat()
+	case FormatJSON:
+		fmtr = log.JSONFormat()
+	case FormatTerminal:
+		fmtr = log.TerminalFormat(false)
+	default:
+		err = fmt.Errorf("unknown log format %s", format)
+		return
+	}
+
+	log.Root().SetHandler(log.LvlFilterHandler(lvl, log.StreamHandler(os.Stdout, fmtr)))
+	return
+}
+
+// RedactURL hides the credentials, path and query of a node url, where providers put API keys
+func RedactURL(node string) string {
+	u, err := url.Parse(node)
+	if err != nil || u.Host == "" {
+		return "<invalid url>"
+	}
+
+	redacted := u.Scheme + "://"
+	if u.User != nil {
+		redacted += "***@"
+	}
+	redacted += u.Host
+	if strings.Trim(u.Path, "/") != "" {
+		redacted += "/***"
+	}
+	if u.RawQuery != "" {
+		redacted += "?***"
+	}
+	return redacted
+}
+
+// RedactKey keeps the first 4 characters of an API key
+func RedactKey(key string) string {
+	if len(key) <= 4 {
+		return "***"
+	}
+	return key[:4] + "***"
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it serves
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// Ctx is logger with the request ID of ctx, if any
+func Ctx(ctx context.Context, logger log.Logger) log.Logger {
+	if ctx != nil {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return logger.New("request_id", id)
+		}
+	}
+	return logger
+}
@@ -0,0 +1,82 @@
+package pricer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+// statuses of a price query
+const (
+	StatusOK          = api.StatusOK
+	StatusNotFound    = api.StatusNotFound
+	StatusRPCError    = api.StatusRPCError
+	StatusNoLiquidity = api.StatusNoLiquidity
+	StatusRejected    = api.StatusRejected
+	StatusTimeout     = api.StatusTimeout
+)
+
+// ErrNotFound is reported as StatusNotFound, for callers to wrap
+var ErrNotFound = errors.New("not found")
+
+// priceError carries the status of a failed price query
+type priceError struct {
+	status string
+	err    error
+}
+
+func (e *priceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *priceError) Unwrap() error {
+	return e.err
+}
+
+// Status classifies err returned by a Pricer, anything unclassified is treated as an rpc error.
+// Calls that ran past the deadline of their context are StatusTimeout.
+func Status(err error) string {
+	if err == nil {
+		return StatusOK
+	}
+	var pe *priceError
+	if errors.As(err, &pe) {
+		return pe.status
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return StatusTimeout
+	}
+	if errors.Is(err, ErrNotFound) {
+		return StatusNotFound
+	}
+	if errors.Is(err, dex.ErrNoPool) || errors.Is(err, dex.ErrNoLiquidity) {
+		return StatusNoLiquidity
+	}
+	return StatusRPCError
+}
+
+// Message describes err by its Status for clients of an api, without the wrapped chain of
+// internal calls or the rpc error text
+func Message(err error) string {
+	if err == nil {
+		return ""
+	}
+	var pe *priceError
+	if errors.As(err, &pe) {
+		return pe.err.Error()
+	}
+	switch Status(err) {
+	case StatusTimeout:
+		return "rpc call timed out"
+	case StatusNotFound:
+		return err.Error()
+	case StatusNoLiquidity:
+		if errors.Is(err, dex.ErrNoPool) {
+			return dex.ErrNoPool.Error()
+		}
+		return dex.ErrNoLiquidity.Error()
+	}
+	return "rpc call failed"
+}
@@ -0,0 +1,72 @@
+package pricer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/zhiqiangxu/dex-price/pkg/filewatch"
+)
+
+// fiatRates serves the fiat rates of Config.FiatRates, reloading the file whenever it is modified
+type fiatRates struct {
+	file   string
+	logger log.Logger
+
+	mu      sync.Mutex
+	watcher *filewatch.Watcher
+	// rates are the units of a fiat currency per USD
+	rates map[string]float64
+}
+
+func newFiatRates(file string, logger log.Logger) (f *fiatRates, err error) {
+	f = &fiatRates{file: file, logger: logger}
+	f.watcher = filewatch.New(f.load, file)
+	err = f.watcher.Reload()
+	if err != nil {
+		f = nil
+	}
+	return
+}
+
+// load reads the file, must be called with mu held or before use
+func (f *fiatRates) load() (err error) {
+	jsonBytes, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		err = fmt.Errorf("ReadFile fail:%v", err)
+		return
+	}
+	var rates map[string]float64
+	err = json.Unmarshal(jsonBytes, &rates)
+	if err != nil {
+		err = fmt.Errorf("fiat rates %s Unmarshal fail:%v", f.file, err)
+		return
+	}
+
+	lowered := make(map[string]float64)
+	for currency, rate := range rates {
+		if rate <= 0 {
+			err = fmt.Errorf("invalid fiat rate %v for %s", rate, currency)
+			return
+		}
+		lowered[strings.ToLower(currency)] = rate
+	}
+	f.rates = lowered
+	return
+}
+
+// rate returns the units of currency per USD, ok is false if currency is unknown.
+// A file that fails to reload keeps serving the last good rates.
+func (f *fiatRates) rate(currency string) (rate float64, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.watcher.Reload(); err != nil {
+		f.logger.Warn("fiat rates reload fail", "file", f.file, "err", err)
+	}
+	rate, ok = f.rates[currency]
+	return
+}
@@ -0,0 +1,129 @@
+package pricer
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+const defaultTWAPWindow = 600
+
+var (
+	q112    = big.NewInt(0).Lsh(big.NewInt(1), 112)
+	uint256 = big.NewInt(0).Lsh(big.NewInt(1), 256)
+)
+
+type observation struct {
+	cumulative *big.Int
+	ts         int64
+}
+
+type guardState struct {
+	lastPrice float64
+	lastTs    int64
+	// observations of the cumulative price, oldest first
+	observations []observation
+}
+
+// checkGuard rejects state if it violates the Guard of route, priceTokenPrice is the USD price of the price token.
+// Historical states are only checked against MinReserveUSD.
+func (p *Pricer) checkGuard(opts *bind.CallOpts, route *tokenRoute, state *pairState, priceTokenPrice float64) (err error) {
+	return p.checkPairGuard(opts, route.guard(), route.swap.Pairs[route.pairIndex], state, priceTokenPrice)
+}
+
+// checkPairGuard rejects state of the pool of pair if it violates guard, the history of the guard is kept per pair
+func (p *Pricer) checkPairGuard(opts *bind.CallOpts, guard *config.Guard, pair *config.Pair, state *pairState, priceTokenPrice float64) (err error) {
+	if guard == nil {
+		return
+	}
+
+	reject := func(format string, args ...interface{}) error {
+		return &priceError{status: StatusRejected, err: fmt.Errorf("%s/%s: %s", pair.TargetTokenName, pair.PriceTokenName, fmt.Sprintf(format, args...))}
+	}
+
+	if guard.MinReserveUSD > 0 {
+		reserveUSD := state.priceReserve * priceTokenPrice
+		if reserveUSD < guard.MinReserveUSD {
+			return reject("reserve %v USD below %v", reserveUSD, guard.MinReserveUSD)
+		}
+	}
+
+	if opts != nil && opts.BlockNumber != nil {
+		return
+	}
+
+	now := time.Now().Unix()
+
+	p.guardMu.Lock()
+	defer p.guardMu.Unlock()
+
+	gs := p.guardStates[pair]
+	if gs == nil {
+		gs = &guardState{}
+		p.guardStates[pair] = gs
+	}
+
+	if guard.MaxDeviationPerMinute > 0 && gs.lastTs > 0 {
+		minutes := math.Max(1, float64(now-gs.lastTs)/60)
+		deviation := math.Abs(state.price-gs.lastPrice) / gs.lastPrice
+		if deviation > guard.MaxDeviationPerMinute*minutes {
+			return reject("price %v deviates %v from last accepted %v", state.price, deviation, gs.lastPrice)
+		}
+	}
+
+	if guard.MaxTWAPDivergence > 0 && state.priceCumulative != nil {
+		window := guard.TWAPWindow
+		if window == 0 {
+			window = defaultTWAPWindow
+		}
+		twap, ok := gs.observe(state, window)
+		if ok {
+			divergence := math.Abs(state.price-twap) / twap
+			if divergence > guard.MaxTWAPDivergence {
+				return reject("spot price %v diverges %v from %ds TWAP %v", state.price, divergence, window, twap)
+			}
+		}
+	}
+
+	gs.lastPrice = state.price
+	gs.lastTs = now
+	return
+}
+
+// observe records the cumulative price of state and returns the TWAP over
+// the shortest period of at least window seconds, ok is false until enough
+// history is available
+func (gs *guardState) observe(state *pairState, window int64) (twap float64, ok bool) {
+	now := state.cumulativeTs
+
+	// keep only the newest observation that is at least window old
+	start := -1
+	for i, o := range gs.observations {
+		if now-o.ts >= window {
+			start = i
+		}
+	}
+	if start > 0 {
+		gs.observations = gs.observations[start:]
+	}
+	if start >= 0 {
+		o := gs.observations[0]
+		diff := big.NewInt(0).Sub(state.priceCumulative, o.cumulative)
+		if diff.Sign() < 0 {
+			diff.Add(diff, uint256)
+		}
+		raw := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(diff), big.NewFloat(0).SetInt(big.NewInt(0).Mul(q112, big.NewInt(now-o.ts))))
+		raw.Mul(raw, dex.Pow10(state.targetTokenDecimals))
+		raw.Quo(raw, dex.Pow10(state.priceTokenDecimals))
+		twap, _ = raw.Float64()
+		ok = twap > 0
+	}
+
+	gs.observations = append(gs.observations, observation{cumulative: state.priceCumulative, ts: now})
+	return
+}
@@ -0,0 +1,158 @@
+package pricer
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+func TestNewPricerRejectsTWAPWithoutOracle(t *testing.T) {
+	guard := &config.Guard{MaxTWAPDivergence: 0.05}
+	pair := &config.Pair{
+		TargetTokenName: "dai",
+		TargetTokenAddr: testWETH.Hex(),
+		PriceTokenName:  "usdt",
+		PriceTokenAddr:  testUSDT.Hex(),
+	}
+	cases := []struct {
+		name string
+		swap *config.Swap
+		// noBlockNumber hides BlockNumber of the backend
+		noBlockNumber bool
+		ok            bool
+	}{
+		{"v2", &config.Swap{Name: "uni", Factory: testFactory1.Hex(), Guard: guard, Pairs: []*config.Pair{pair}}, false, true},
+		{"v2 without BlockNumber", &config.Swap{Name: "uni", Factory: testFactory1.Hex(), Guard: guard, Pairs: []*config.Pair{pair}}, true, false},
+		{"curve swap guard", &config.Swap{Name: "curve", Type: "curve", Guard: guard, Pairs: []*config.Pair{pair}}, false, false},
+		{"curve pair guard", &config.Swap{Name: "curve", Type: "curve", Pairs: []*config.Pair{{
+			TargetTokenName: pair.TargetTokenName,
+			TargetTokenAddr: pair.TargetTokenAddr,
+			PriceTokenName:  pair.PriceTokenName,
+			PriceTokenAddr:  pair.PriceTokenAddr,
+			Guard:           guard,
+		}}}, false, false},
+	}
+	for _, c := range cases {
+		chain := &config.Chain{Name: "eth", StableCoins: []string{"usdt"}, Swaps: []*config.Swap{c.swap}}
+		var backend bind.ContractCaller = newFakeChain()
+		if c.noBlockNumber {
+			backend = struct{ bind.ContractCaller }{backend}
+		}
+		_, err := NewPricer(Config{Chains: []*config.Chain{chain}, Backends: map[string]bind.ContractCaller{"eth": backend}})
+		if c.ok && err != nil {
+			t.Fatalf("%s: NewPricer fail:%v", c.name, err)
+		}
+		if !c.ok && err == nil {
+			t.Fatalf("%s: NewPricer accepted MaxTWAPDivergence without a TWAP", c.name)
+		}
+	}
+}
+
+func TestTWAPReadsOneBlock(t *testing.T) {
+	chain := newFakeChain()
+	chain.token(testWETH, 18)
+	chain.token(testUSDT, 6)
+	chain.factory(testFactory1, map[[2]common.Address]common.Address{{testWETH, testUSDT}: testEthPair})
+	chain.pair(testEthPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), units(1, 18))
+	chain.set(testEthPair, "price0CumulativeLast", returns(big.NewInt(0)))
+	chain.number = 100
+
+	pair := &config.Pair{
+		TargetTokenName: "eth",
+		TargetTokenAddr: testWETH.Hex(),
+		PriceTokenName:  "usdt",
+		PriceTokenAddr:  testUSDT.Hex(),
+		Guard:           &config.Guard{MaxTWAPDivergence: 0.05},
+	}
+	swap := &config.Swap{Name: "uni", Factory: testFactory1.Hex(), Pairs: []*config.Pair{pair}}
+	conf := &config.Chain{Name: "eth", StableCoins: []string{"usdt"}, Swaps: []*config.Swap{swap}}
+	p, err := NewPricer(Config{Chains: []*config.Chain{conf}, Backends: map[string]bind.ContractCaller{"eth": chain}})
+	if err != nil {
+		t.Fatalf("NewPricer fail:%v", err)
+	}
+
+	// latest reads the reserves and the cumulative price at the same block
+	_, _, err = p.tokenPrice(&bind.CallOpts{Context: context.Background()}, "eth", 0)
+	if err != nil {
+		t.Fatalf("tokenPrice fail:%v", err)
+	}
+	reserves, cumulatives := chain.callBlocks["getReserves"], chain.callBlocks["price0CumulativeLast"]
+	if len(reserves) != 1 || len(cumulatives) != 1 || reserves[0] == nil || reserves[0].Uint64() != 100 || cumulatives[0] == nil || cumulatives[0].Uint64() != 100 {
+		t.Fatalf("getReserves at %v and price0CumulativeLast at %v, want both at block 100", reserves, cumulatives)
+	}
+
+	// historical queries skip the TWAP
+	_, _, err = p.tokenPrice(&bind.CallOpts{Context: context.Background(), BlockNumber: big.NewInt(90)}, "eth", 0)
+	if err != nil {
+		t.Fatalf("historical tokenPrice fail:%v", err)
+	}
+	if cumulatives := chain.callBlocks["price0CumulativeLast"]; len(cumulatives) != 1 {
+		t.Fatalf("price0CumulativeLast read %d times, want once", len(cumulatives))
+	}
+}
+
+func TestGuardObserve(t *testing.T) {
+	type step struct {
+		// the raw price num/den held for dt seconds before the observation
+		dt       int64
+		num, den int64
+		twap     float64
+		ok       bool
+	}
+	nearMax := big.NewInt(0).Sub(uint256, big.NewInt(0).Mul(q112, big.NewInt(100)))
+	cases := []struct {
+		name               string
+		targetDecimals     uint8
+		priceDecimals      uint8
+		window             int64
+		cumulative         *big.Int
+		steps              []step
+		observationsAtLast int
+	}{
+		{"window", 18, 18, 600, big.NewInt(0), []step{
+			{0, 0, 1, 0, false},
+			{300, 2, 1, 0, false},
+			{300, 3, 1, 2.5, true},
+			// the observation 600s old replaces the one 900s old
+			{300, 4, 1, 3.5, true},
+			{600, 5, 1, 5, true},
+		}, 2},
+		{"decimals", 18, 6, 600, big.NewInt(0), []step{
+			{0, 0, 1, 0, false},
+			{600, 2, 1000000000, 2000, true},
+		}, 2},
+		{"cumulative overflow", 18, 18, 600, nearMax, []step{
+			{0, 0, 1, 0, false},
+			{600, 1, 1, 1, true},
+		}, 2},
+	}
+	for _, c := range cases {
+		gs := &guardState{}
+		cumulative := big.NewInt(0).Set(c.cumulative)
+		ts := int64(1600000000)
+		for i, s := range c.steps {
+			ts += s.dt
+			delta := big.NewInt(0).Mul(q112, big.NewInt(s.num*s.dt))
+			cumulative.Add(cumulative, delta.Div(delta, big.NewInt(s.den)))
+			cumulative.Mod(cumulative, uint256)
+			state := &pairState{
+				targetTokenDecimals: c.targetDecimals,
+				priceTokenDecimals:  c.priceDecimals,
+				priceCumulative:     big.NewInt(0).Set(cumulative),
+				cumulativeTs:        ts,
+			}
+			twap, ok := gs.observe(state, c.window)
+			if ok != s.ok || math.Abs(twap-s.twap) > s.twap*1e-9 {
+				t.Fatalf("%s step %d: twap %v %v, want %v %v", c.name, i, twap, ok, s.twap, s.ok)
+			}
+		}
+		if len(gs.observations) != c.observationsAtLast {
+			t.Fatalf("%s: %d observations kept, want %d", c.name, len(gs.observations), c.observationsAtLast)
+		}
+	}
+}
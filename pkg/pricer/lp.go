@@ -0,0 +1,151 @@
+package pricer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+func (p *Pricer) updateLPConstant(opts *bind.CallOpts, route *lpRoute) (constant *lpConstant, err error) {
+	lp := route.swap.LPTokens[route.lpIndex]
+	d := p.dexes[route.swap]
+	if _, ok := d.(dex.ConstantProduct); !ok {
+		err = fmt.Errorf("lp token %s is not a constant product pool", lp.Name)
+		return
+	}
+	if _, ok := d.(dex.LPPool); !ok {
+		err = fmt.Errorf("lp token %s is not a pool token", lp.Name)
+		return
+	}
+
+	pool, err := d.LoadPool(latestOpts(opts), common.HexToAddress(lp.PairAddr))
+	if err != nil {
+		err = fmt.Errorf("LoadPool fail:%w", err)
+		return
+	}
+	token0Name, token1Name := p.tokenNames[pool.Tokens[0]], p.tokenNames[pool.Tokens[1]]
+	if token0Name == "" || token1Name == "" {
+		err = &priceError{status: StatusNotFound, err: fmt.Errorf("underlying tokens of %s not configured", lp.Name)}
+		return
+	}
+
+	// routes whose pool is only known once resolved are checked here instead of in NewPricer
+	for _, token := range []string{token0Name, token1Name} {
+		var uses bool
+		uses, err = p.routeUses(opts, token, pool.Address)
+		if err != nil {
+			err = fmt.Errorf("routeUses fail:%w", err)
+			return
+		}
+		if uses {
+			err = &priceError{status: StatusRejected, err: fmt.Errorf("lp token %s is priced through its own pool by %s", lp.Name, token)}
+			return
+		}
+	}
+
+	decimals, err := p.Decimals(optsContext(opts), route.chain.Name, pool.Address)
+	if err != nil {
+		return
+	}
+
+	constant = &lpConstant{
+		pool:       pool,
+		token0Name: token0Name,
+		token1Name: token1Name,
+		decimals:   decimals,
+	}
+
+	p.constantMu.Lock()
+	p.lpConstants[lp.Name] = constant
+	p.constantMu.Unlock()
+	return
+}
+
+// routeUses is true if the price of token is read from the pool at addr on its way to a stable coin
+func (p *Pricer) routeUses(opts *bind.CallOpts, token string, addr common.Address) (uses bool, err error) {
+	for hops := 0; hops < maxRouteHops; hops++ {
+		if chain := p.natives[token]; chain != nil {
+			var pools []*nativePool
+			pools, err = p.nativePools(opts, chain)
+			if err != nil {
+				return
+			}
+			for _, np := range pools {
+				if np.tp.pool.Address == addr {
+					uses = true
+					return
+				}
+			}
+			return
+		}
+
+		route := p.routes[token]
+		if route == nil {
+			return
+		}
+		var tp *tokenPool
+		tp, err = p.tokenPool(opts, route)
+		if err != nil {
+			return
+		}
+		if tp.pool.Address == addr {
+			uses = true
+			return
+		}
+		token = route.swap.Pairs[route.pairIndex].PriceTokenName
+	}
+	return
+}
+
+// lpPrice values one LP token with the fair reserve formula 2*sqrt(k*p0*p1)/totalSupply.
+// p0 and p1 are priced through routes that avoid the pair, checked by NewPricer and updateLPConstant,
+// and k is invariant under swaps, so skewing the reserves within the pair does not move the result.
+func (p *Pricer) lpPrice(opts *bind.CallOpts, route *lpRoute, hops int) (price float64, warnings []string, err error) {
+	lp := route.swap.LPTokens[route.lpIndex]
+
+	p.constantMu.RLock()
+	constant := p.lpConstants[lp.Name]
+	p.constantMu.RUnlock()
+	if constant == nil {
+		constant, err = p.updateLPConstant(opts, route)
+		if err != nil {
+			err = fmt.Errorf("updateLPConstant fail:%w", err)
+			return
+		}
+	}
+
+	d := p.dexes[route.swap]
+	state, err := d.State(opts, constant.pool)
+	if err != nil {
+		err = fmt.Errorf("State fail:%w", err)
+		return
+	}
+	totalSupply, err := d.(dex.LPPool).TotalSupply(opts, constant.pool)
+	if err != nil {
+		err = fmt.Errorf("TotalSupply fail:%w", err)
+		return
+	}
+	if totalSupply.Sign() == 0 || state.Reserves[0].Sign() == 0 || state.Reserves[1].Sign() == 0 {
+		err = &priceError{status: StatusNoLiquidity, err: fmt.Errorf("lp token %s has no liquidity", lp.Name)}
+		return
+	}
+
+	price0, warnings0, err := p.tokenPrice(opts, constant.token0Name, hops+1)
+	if err != nil {
+		err = fmt.Errorf("token0 %s price fail:%w", constant.token0Name, err)
+		return
+	}
+	price1, warnings1, err := p.tokenPrice(opts, constant.token1Name, hops+1)
+	if err != nil {
+		err = fmt.Errorf("token1 %s price fail:%w", constant.token1Name, err)
+		return
+	}
+	warnings = append(warnings0, warnings1...)
+
+	k := dex.DecimalAdjust(state.Reserves[0], constant.pool.Decimals[0]) * dex.DecimalAdjust(state.Reserves[1], constant.pool.Decimals[1])
+	price = 2 * math.Sqrt(k*price0*price1) / dex.DecimalAdjust(totalSupply, constant.decimals)
+	return
+}
@@ -0,0 +1,114 @@
+package pricer
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+var (
+	testWETH     = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	testUSDT     = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	testFactory1 = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	testFactory2 = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+	testLPPair   = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	testEthPair  = common.HexToAddress("0x00000000000000000000000000000000000000c2")
+)
+
+var testEthPairConf = &config.Pair{
+	TargetTokenName: "eth",
+	TargetTokenAddr: testWETH.Hex(),
+	PriceTokenName:  "usdt",
+	PriceTokenAddr:  testUSDT.Hex(),
+}
+
+// lpTestChain has the weth/usdt LP token on swap uni, with eth priced on ethSwap
+func lpTestChain(ethSwap string) *config.Chain {
+	uniSwap := &config.Swap{Name: "uni", Factory: testFactory1.Hex(), LPTokens: []*config.LPToken{{Name: "uni-eth-usdt", PairAddr: testLPPair.Hex()}}}
+	sushiSwap := &config.Swap{Name: "sushi", Factory: testFactory2.Hex()}
+	if ethSwap == "uni" {
+		uniSwap.Pairs = []*config.Pair{testEthPairConf}
+	} else {
+		sushiSwap.Pairs = []*config.Pair{testEthPairConf}
+	}
+	return &config.Chain{Name: "eth", StableCoins: []string{"usdt"}, Swaps: []*config.Swap{uniSwap, sushiSwap}}
+}
+
+func TestLPPriceResistsSkewedReserves(t *testing.T) {
+	chain := newFakeChain()
+	chain.token(testWETH, 18)
+	chain.token(testUSDT, 6)
+	chain.factory(testFactory1, map[[2]common.Address]common.Address{{testWETH, testUSDT}: testLPPair})
+	chain.factory(testFactory2, map[[2]common.Address]common.Address{{testWETH, testUSDT}: testEthPair})
+	// both pools price eth at 2000 usdt
+	chain.pair(testEthPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), units(1, 18))
+	totalSupply := units(100, 18)
+	chain.pair(testLPPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), totalSupply)
+
+	p, err := NewPricer(Config{Chains: []*config.Chain{lpTestChain("sushi")}, Backends: map[string]bind.ContractCaller{"eth": chain}})
+	if err != nil {
+		t.Fatalf("NewPricer fail:%v", err)
+	}
+	opts := &bind.CallOpts{Context: context.Background()}
+
+	balanced, _, err := p.tokenPrice(opts, "uni-eth-usdt", 0)
+	if err != nil {
+		t.Fatalf("tokenPrice fail:%v", err)
+	}
+	// a balanced pool is worth its reserves
+	if want := (1000*2000.0 + 2000000) / 100; math.Abs(balanced-want) > want*1e-9 {
+		t.Fatalf("balanced price %v, want %v", balanced, want)
+	}
+
+	// a flash loan swaps 3000 eth in and 1500000 usdt out, keeping k
+	chain.reserves(testLPPair, units(4000, 18), units(500000, 6))
+	skewed, _, err := p.tokenPrice(opts, "uni-eth-usdt", 0)
+	if err != nil {
+		t.Fatalf("tokenPrice fail:%v", err)
+	}
+	if math.Abs(skewed-balanced) > balanced*1e-9 {
+		t.Fatalf("skewed price %v moved from %v", skewed, balanced)
+	}
+	if naive := (4000*2000.0 + 500000) / 100; math.Abs(naive-balanced) < balanced*0.1 {
+		t.Fatalf("skew too small to tell fair from naive pricing:%v", naive)
+	}
+}
+
+func TestNewPricerRejectsLPPricedThroughItsPool(t *testing.T) {
+	chain := lpTestChain("uni")
+	uniSwap := chain.Swaps[0]
+	uniSwap.InitCodeHash = "0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
+	lpAddr, ok := dex.PoolAddress(uniSwap, testEthPairConf)
+	if !ok {
+		t.Fatal("PoolAddress not known offline")
+	}
+	uniSwap.LPTokens[0].PairAddr = lpAddr.Hex()
+
+	_, err := NewPricer(Config{Chains: []*config.Chain{chain}, Backends: map[string]bind.ContractCaller{"eth": newFakeChain()}})
+	if err == nil {
+		t.Fatal("NewPricer accepted an lp token priced through its own pool")
+	}
+}
+
+func TestLPPriceRejectsRouteThroughItsPool(t *testing.T) {
+	chain := newFakeChain()
+	chain.token(testWETH, 18)
+	chain.token(testUSDT, 6)
+	// without an InitCodeHash the eth pool is only known once resolved
+	chain.factory(testFactory1, map[[2]common.Address]common.Address{{testWETH, testUSDT}: testLPPair})
+	chain.pair(testLPPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), units(100, 18))
+
+	p, err := NewPricer(Config{Chains: []*config.Chain{lpTestChain("uni")}, Backends: map[string]bind.ContractCaller{"eth": chain}})
+	if err != nil {
+		t.Fatalf("NewPricer fail:%v", err)
+	}
+	_, _, err = p.tokenPrice(&bind.CallOpts{Context: context.Background()}, "uni-eth-usdt", 0)
+	if status := Status(err); status != StatusRejected {
+		t.Fatalf("status %s, want %s:%v", status, StatusRejected, err)
+	}
+}
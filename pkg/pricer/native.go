@@ -0,0 +1,147 @@
+package pricer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+// nativePool is a pool of the wrapped native token against a stable coin
+type nativePool struct {
+	swap *config.Swap
+	// pair is the pool as a pair of the native token, guarded by the Guard of swap
+	pair *config.Pair
+	tp   *tokenPool
+}
+
+// nativePools resolves the pools of the wrapped native token of chain against
+// every stable coin of chain on every swap, an empty result is not cached so pools deployed later are found
+func (p *Pricer) nativePools(opts *bind.CallOpts, chain *config.Chain) (pools []*nativePool, err error) {
+	p.constantMu.RLock()
+	pools, ok := p.nativePoolCache[chain.Name]
+	p.constantMu.RUnlock()
+	if ok {
+		return
+	}
+
+	wrapped := common.HexToAddress(chain.Native.Wrapped)
+	for _, swap := range chain.Swaps {
+		d := p.dexes[swap]
+		for _, stable := range chain.StableCoins {
+			stableAddr, ok := p.tokenAddrs[stable]
+			if !ok {
+				continue
+			}
+			pair := &config.Pair{
+				TargetTokenName: chain.Native.Symbol,
+				TargetTokenAddr: wrapped.Hex(),
+				PriceTokenName:  stable,
+				PriceTokenAddr:  stableAddr.Hex(),
+			}
+			var pool *dex.Pool
+			pool, err = d.ResolvePool(latestOpts(opts), pair)
+			if err != nil {
+				if errors.Is(err, dex.ErrNoPool) {
+					err = nil
+					continue
+				}
+				err = fmt.Errorf("ResolvePool fail:%w", err)
+				return
+			}
+			pools = append(pools, &nativePool{
+				swap: swap,
+				pair: pair,
+				tp:   &tokenPool{pool: pool, target: pool.Index(wrapped), price: pool.Index(stableAddr)},
+			})
+		}
+	}
+	if len(pools) == 0 {
+		return
+	}
+
+	p.constantMu.Lock()
+	p.nativePoolCache[chain.Name] = pools
+	p.constantMu.Unlock()
+	return
+}
+
+// nativePrice prices the native token of chain, for which no pair is configured,
+// in the pool against a stable coin with the deepest stable coin reserve, checked against the Guard of its swap
+func (p *Pricer) nativePrice(opts *bind.CallOpts, chain *config.Chain, hops int) (price float64, warnings []string, err error) {
+	pools, err := p.nativePools(opts, chain)
+	if err != nil {
+		err = fmt.Errorf("nativePools fail:%w", err)
+		return
+	}
+
+	var (
+		best     *pairState
+		bestPool *nativePool
+	)
+	for _, np := range pools {
+		d := p.dexes[np.swap]
+		var poolState *dex.State
+		poolState, err = d.State(opts, np.tp.pool)
+		if err != nil {
+			if errors.Is(err, dex.ErrNoPool) {
+				err = nil
+				continue
+			}
+			err = fmt.Errorf("State fail:%w", err)
+			return
+		}
+		var state *pairState
+		state, err = newPairState(d, np.tp, poolState)
+		if err != nil {
+			if errors.Is(err, dex.ErrNoLiquidity) {
+				err = nil
+				continue
+			}
+			return
+		}
+		if best == nil || state.priceReserve > best.priceReserve {
+			best, bestPool = state, np
+		}
+	}
+	if best == nil {
+		err = fmt.Errorf("native token %s has no pool against stableCoins of chain %s, %w", chain.Native.Symbol, chain.Name, dex.ErrNoPool)
+		return
+	}
+
+	d := p.dexes[bestPool.swap]
+	stateOpts, twap, err := p.twapOpts(opts, chain.Name, bestPool.swap.Guard, d)
+	if err != nil {
+		return
+	}
+	if twap {
+		// read the selected pool again at the block its cumulative price is read at
+		var poolState *dex.State
+		poolState, err = d.State(stateOpts, bestPool.tp.pool)
+		if err != nil {
+			err = fmt.Errorf("State fail:%w", err)
+			return
+		}
+		best, err = newPairState(d, bestPool.tp, poolState)
+		if err != nil {
+			return
+		}
+		err = loadCumulative(stateOpts, d.(dex.Oracle), bestPool.tp, poolState, best)
+		if err != nil {
+			return
+		}
+	}
+	stablePrice, warnings, err := p.tokenPrice(opts, bestPool.pair.PriceTokenName, hops+1)
+	if err != nil {
+		return
+	}
+	err = p.checkPairGuard(opts, bestPool.swap.Guard, bestPool.pair, best, stablePrice)
+	if err != nil {
+		return
+	}
+	price = best.price * stablePrice
+	return
+}
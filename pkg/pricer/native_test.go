@@ -0,0 +1,91 @@
+package pricer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+var (
+	testO3     = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+	testO3Pair = common.HexToAddress("0x00000000000000000000000000000000000000c3")
+)
+
+// nativeTestChain prices eth natively through uni, where only o3/usdt is configured
+func nativeTestChain(guard *config.Guard) *config.Chain {
+	swap := &config.Swap{Name: "uni", Factory: testFactory1.Hex(), Guard: guard, Pairs: []*config.Pair{{
+		TargetTokenName: "o3",
+		TargetTokenAddr: testO3.Hex(),
+		PriceTokenName:  "usdt",
+		PriceTokenAddr:  testUSDT.Hex(),
+	}}}
+	return &config.Chain{
+		Name:        "eth",
+		StableCoins: []string{"usdt"},
+		Swaps:       []*config.Swap{swap},
+		Native:      &config.Native{Symbol: "eth", Wrapped: testWETH.Hex()},
+	}
+}
+
+func TestNativePriceFindsLaterPool(t *testing.T) {
+	chain := newFakeChain()
+	chain.token(testWETH, 18)
+	chain.token(testUSDT, 6)
+	pairs := map[[2]common.Address]common.Address{{testO3, testUSDT}: testO3Pair}
+	chain.factory(testFactory1, pairs)
+
+	p, err := NewPricer(Config{Chains: []*config.Chain{nativeTestChain(nil)}, Backends: map[string]bind.ContractCaller{"eth": chain}})
+	if err != nil {
+		t.Fatalf("NewPricer fail:%v", err)
+	}
+	opts := &bind.CallOpts{Context: context.Background()}
+
+	_, _, err = p.tokenPrice(opts, "eth", 0)
+	if !errors.Is(err, dex.ErrNoPool) {
+		t.Fatalf("tokenPrice without a pool:%v, want ErrNoPool", err)
+	}
+
+	// the pool is deployed after the first lookup
+	chain.pair(testEthPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), units(1, 18))
+	pairs[[2]common.Address{testWETH, testUSDT}] = testEthPair
+	price, _, err := p.tokenPrice(opts, "eth", 0)
+	if err != nil {
+		t.Fatalf("tokenPrice fail:%v", err)
+	}
+	if math.Abs(price-2000) > 2000*1e-9 {
+		t.Fatalf("price %v, want 2000", price)
+	}
+}
+
+func TestNativePriceGuard(t *testing.T) {
+	chain := newFakeChain()
+	chain.token(testWETH, 18)
+	chain.token(testUSDT, 6)
+	chain.factory(testFactory1, map[[2]common.Address]common.Address{{testWETH, testUSDT}: testEthPair})
+	chain.pair(testEthPair, testWETH, testUSDT, units(1000, 18), units(2000000, 6), units(1, 18))
+
+	cases := []struct {
+		name   string
+		guard  *config.Guard
+		status string
+	}{
+		{"deep enough", &config.Guard{MinReserveUSD: 1000000}, StatusOK},
+		{"too shallow", &config.Guard{MinReserveUSD: 5000000}, StatusRejected},
+	}
+	for _, c := range cases {
+		p, err := NewPricer(Config{Chains: []*config.Chain{nativeTestChain(c.guard)}, Backends: map[string]bind.ContractCaller{"eth": chain}})
+		if err != nil {
+			t.Fatalf("%s: NewPricer fail:%v", c.name, err)
+		}
+		_, _, err = p.tokenPrice(&bind.CallOpts{Context: context.Background()}, "eth", 0)
+		if Status(err) != c.status {
+			t.Fatalf("%s: status %s (%v), want %s", c.name, Status(err), err, c.status)
+		}
+	}
+}
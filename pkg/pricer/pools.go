@@ -0,0 +1,116 @@
+package pricer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+// PoolToken ...
+type PoolToken = api.PoolToken
+
+// PoolInfo ...
+type PoolInfo = api.PoolInfo
+
+// Pools lists the pools on the route from token to its stable coin
+func (p *Pricer) Pools(ctx context.Context, token string) (pools []PoolInfo, err error) {
+	if p.routes[token] == nil {
+		err = &priceError{status: StatusNotFound, err: fmt.Errorf("token not found:%s", token)}
+		return
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	for hops := 0; hops < maxRouteHops; hops++ {
+		route := p.routes[token]
+		if route == nil {
+			break
+		}
+		var pool *PoolInfo
+		pool, err = p.queryPool(opts, route)
+		if err != nil {
+			err = fmt.Errorf("queryPool fail:%w", err)
+			return
+		}
+		pools = append(pools, *pool)
+
+		token = route.swap.Pairs[route.pairIndex].PriceTokenName
+		if chain := p.stableCoins[token]; chain != nil && (chain.Depeg == nil || chain.Depeg.Reference == token) {
+			break
+		}
+	}
+	return
+}
+
+func (p *Pricer) queryPool(opts *bind.CallOpts, route *tokenRoute) (pool *PoolInfo, err error) {
+	tp, err := p.tokenPool(opts, route)
+	if err != nil {
+		return
+	}
+
+	d := p.dexes[route.swap]
+	poolState, err := d.State(opts, tp.pool)
+	if err != nil {
+		err = fmt.Errorf("State fail:%w", err)
+		return
+	}
+	state, err := newPairState(d, tp, poolState)
+	if err != nil {
+		return
+	}
+
+	pair := route.swap.Pairs[route.pairIndex]
+	priceTokenPrice, _, err := p.tokenPrice(opts, pair.PriceTokenName, 1)
+	if err != nil {
+		err = fmt.Errorf("priceToken %s price fail:%w", pair.PriceTokenName, err)
+		return
+	}
+
+	target := PoolToken{
+		Symbol:     pair.TargetTokenName,
+		Addr:       common.HexToAddress(pair.TargetTokenAddr).Hex(),
+		Decimals:   state.targetTokenDecimals,
+		RawReserve: state.rawTargetReserve.String(),
+		Reserve:    state.targetReserve,
+		Price:      state.price * priceTokenPrice,
+	}
+	price := PoolToken{
+		Symbol:     pair.PriceTokenName,
+		Addr:       common.HexToAddress(pair.PriceTokenAddr).Hex(),
+		Decimals:   state.priceTokenDecimals,
+		RawReserve: state.rawPriceReserve.String(),
+		Reserve:    state.priceReserve,
+		Price:      priceTokenPrice,
+	}
+
+	pool = &PoolInfo{
+		Swap:               route.swap.Name,
+		Pair:               tp.pool.Address.Hex(),
+		TVL:                target.Reserve*target.Price + price.Reserve*price.Price,
+		BlockTimestampLast: state.timestamp,
+	}
+	if tp.target < tp.price {
+		pool.Token0, pool.Token1 = target, price
+	} else {
+		pool.Token0, pool.Token1 = price, target
+	}
+
+	if lpPool, ok := d.(dex.LPPool); ok {
+		totalSupply, err := lpPool.TotalSupply(opts, tp.pool)
+		if err != nil {
+			return nil, fmt.Errorf("TotalSupply fail:%w", err)
+		}
+		kLast, err := lpPool.KLast(opts, tp.pool)
+		if err != nil {
+			return nil, fmt.Errorf("KLast fail:%w", err)
+		}
+		pool.TotalSupply = totalSupply.String()
+		if kLast != nil {
+			pool.KLast = kLast.String()
+		}
+	}
+	return
+}
@@ -0,0 +1,320 @@
+package pricer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+	"github.com/zhiqiangxu/dex-price/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRouteHops bounds the number of pairs walked from a token to a stable coin
+const maxRouteHops = 4
+
+// tokenPrice returns the USD price of token, along with warnings for depegged stable coins on its route
+func (p *Pricer) tokenPrice(opts *bind.CallOpts, token string, hops int) (price float64, warnings []string, err error) {
+	if p.stableCoins[token] != nil {
+		return p.stablePrice(opts, token, hops)
+	}
+	if lp := p.lpRoutes[token]; lp != nil {
+		return p.lpPrice(opts, lp, hops)
+	}
+	if chain := p.natives[token]; chain != nil {
+		return p.nativePrice(opts, chain, hops)
+	}
+
+	if hops >= maxRouteHops {
+		err = &priceError{status: StatusNotFound, err: fmt.Errorf("no stableCoin within %d hops for %s", maxRouteHops, token)}
+		return
+	}
+
+	tokenRoute := p.routes[token]
+	if tokenRoute == nil {
+		if hops == 0 {
+			err = &priceError{status: StatusNotFound, err: fmt.Errorf("token not found:%s", token)}
+		} else {
+			err = &priceError{status: StatusNotFound, err: fmt.Errorf("priceToken not found:%s", token)}
+		}
+		return
+	}
+
+	state, err := p.queryPrice(opts, tokenRoute)
+	if err != nil {
+		err = fmt.Errorf("queryPrice fail:%w", err)
+		return
+	}
+	priceToken := tokenRoute.swap.Pairs[tokenRoute.pairIndex].PriceTokenName
+	priceTokenPrice, warnings, err := p.tokenPrice(opts, priceToken, hops+1)
+	if err != nil {
+		return
+	}
+	err = p.checkGuard(opts, tokenRoute, state, priceTokenPrice)
+	if err != nil {
+		return
+	}
+	price = state.price * priceTokenPrice
+	return
+}
+
+// stablePrice returns the USD price of a stable coin.
+// Without Depeg configured every stable coin is worth exactly 1 USD, otherwise
+// it is priced through its pair against the other stable coins and only
+// deviates from 1 USD once the deviation passes the configured threshold.
+func (p *Pricer) stablePrice(opts *bind.CallOpts, token string, hops int) (price float64, warnings []string, err error) {
+	chain := p.stableCoins[token]
+	depeg := chain.Depeg
+	if depeg == nil {
+		price = 1
+		return
+	}
+
+	if hops >= maxRouteHops {
+		err = fmt.Errorf("stableCoin %s not priced against %s within %d hops", token, depeg.Reference, maxRouteHops)
+		return
+	}
+
+	if token == depeg.Reference {
+		price = depeg.FiatPrice
+		if price == 0 {
+			price = 1
+		}
+	} else {
+		tokenRoute := p.routes[token]
+		if tokenRoute == nil {
+			// no pool to measure the peg with
+			price = 1
+			return
+		}
+
+		var state *pairState
+		state, err = p.queryPrice(opts, tokenRoute)
+		if err != nil {
+			err = fmt.Errorf("stableCoin %s queryPrice fail:%w", token, err)
+			return
+		}
+		priceToken := tokenRoute.swap.Pairs[tokenRoute.pairIndex].PriceTokenName
+		if p.stableCoins[priceToken] != chain {
+			err = fmt.Errorf("stableCoin %s must be priced against another stableCoin of chain %s", token, chain.Name)
+			return
+		}
+		var priceTokenPrice float64
+		priceTokenPrice, warnings, err = p.stablePrice(opts, priceToken, hops+1)
+		if err != nil {
+			return
+		}
+		err = p.checkGuard(opts, tokenRoute, state, priceTokenPrice)
+		if err != nil {
+			return
+		}
+		price = state.price * priceTokenPrice
+	}
+
+	if math.Abs(price-1) <= depeg.Threshold {
+		price = 1
+		return
+	}
+
+	warnings = append(warnings, fmt.Sprintf("stableCoin %s depegged:%v", token, price))
+	return
+}
+
+// latestOpts keeps the context of opts but reads the latest block, for metadata cached across blocks
+func latestOpts(opts *bind.CallOpts) *bind.CallOpts {
+	if opts == nil {
+		return nil
+	}
+	return &bind.CallOpts{Context: opts.Context}
+}
+
+func (p *Pricer) updateTokenPool(opts *bind.CallOpts, route *tokenRoute) (tp *tokenPool, err error) {
+	pair := route.swap.Pairs[route.pairIndex]
+	pool, err := p.dexes[route.swap].ResolvePool(latestOpts(opts), pair)
+	if err != nil {
+		err = fmt.Errorf("ResolvePool fail:%w", err)
+		return
+	}
+
+	tp = &tokenPool{
+		pool:   pool,
+		target: pool.Index(common.HexToAddress(pair.TargetTokenAddr)),
+		price:  pool.Index(common.HexToAddress(pair.PriceTokenAddr)),
+	}
+
+	p.constantMu.Lock()
+	p.tokenPools[pair.TargetTokenName] = tp
+	p.constantMu.Unlock()
+	return
+}
+
+func (p *Pricer) tokenPool(opts *bind.CallOpts, route *tokenRoute) (tp *tokenPool, err error) {
+	pair := route.swap.Pairs[route.pairIndex]
+	p.constantMu.RLock()
+	tp = p.tokenPools[pair.TargetTokenName]
+	p.constantMu.RUnlock()
+	if tp == nil {
+		tp, err = p.updateTokenPool(opts, route)
+		if err != nil {
+			err = fmt.Errorf("updateTokenPool fail:%w", err)
+			return
+		}
+	}
+	return
+}
+
+func (p *Pricer) queryPrice(opts *bind.CallOpts, route *tokenRoute) (state *pairState, err error) {
+	pair := route.swap.Pairs[route.pairIndex]
+	ctx, span := p.tracer.Start(optsContext(opts), "queryPrice", trace.WithAttributes(
+		attribute.String("swap", route.swap.Name),
+		attribute.String("token", pair.TargetTokenName),
+		attribute.String("price_token", pair.PriceTokenName),
+	))
+	opts = withContext(opts, ctx)
+	defer func() {
+		endSpan(span, err)
+		if err != nil {
+			logger.Ctx(optsContext(opts), p.logger).Warn("queryPrice fail", "swap", route.swap.Name, "token", pair.TargetTokenName, "err", err)
+		}
+	}()
+
+	tp, err := p.tokenPool(opts, route)
+	if err != nil {
+		return
+	}
+
+	d := p.dexes[route.swap]
+	stateOpts, twap, err := p.twapOpts(opts, route.chain.Name, route.guard(), d)
+	if err != nil {
+		return
+	}
+	poolState, err := d.State(stateOpts, tp.pool)
+	if err != nil {
+		err = fmt.Errorf("State fail:%w", err)
+		return
+	}
+
+	state, err = newPairState(d, tp, poolState)
+	if err != nil {
+		return
+	}
+	p.observeReserves(route, state)
+
+	if twap {
+		err = loadCumulative(stateOpts, d.(dex.Oracle), tp, poolState, state)
+	}
+	return
+}
+
+// blockNumberReader is implemented by backends able to tell the latest block, see Config.Backends
+type blockNumberReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// twapOpts returns the opts to read a pool of d with and whether guard needs its cumulative price.
+// Historical queries skip the TWAP, latest ones that need it are pinned to the current block
+// so that the reserves and the cumulative price are read at the same block.
+func (p *Pricer) twapOpts(opts *bind.CallOpts, chain string, guard *config.Guard, d dex.Dex) (stateOpts *bind.CallOpts, twap bool, err error) {
+	stateOpts = opts
+	if guard == nil || guard.MaxTWAPDivergence == 0 || (opts != nil && opts.BlockNumber != nil) {
+		return
+	}
+	if _, ok := d.(dex.Oracle); !ok {
+		return
+	}
+	backend, ok := p.conf.Backends[chain].(blockNumberReader)
+	if !ok {
+		err = fmt.Errorf("backend of chain %s has no BlockNumber to pin the TWAP with", chain)
+		return
+	}
+	number, err := backend.BlockNumber(optsContext(opts))
+	if err != nil {
+		err = fmt.Errorf("BlockNumber fail:%w", err)
+		return
+	}
+	stateOpts = &bind.CallOpts{Context: optsContext(opts), BlockNumber: big.NewInt(0).SetUint64(number)}
+	twap = true
+	return
+}
+
+// loadCumulative loads the cumulative price of state, opts must pin the block poolState was read at
+func loadCumulative(opts *bind.CallOpts, oracle dex.Oracle, tp *tokenPool, poolState *dex.State, state *pairState) (err error) {
+	state.cumulativeTs = time.Now().Unix()
+	state.priceCumulative, err = oracle.CumulativePrice(opts, tp.pool, poolState, tp.target, state.cumulativeTs)
+	if err != nil {
+		err = fmt.Errorf("CumulativePrice fail:%w", err)
+	}
+	return
+}
+
+// pairState is a snapshot of a pool, seen from the target token of a pair
+type pairState struct {
+	// price of target token in price token units
+	price float64
+	// decimal adjusted reserves
+	targetReserve float64
+	priceReserve  float64
+	// raw reserves
+	rawTargetReserve *big.Int
+	rawPriceReserve  *big.Int
+
+	targetTokenDecimals uint8
+	priceTokenDecimals  uint8
+	timestamp           uint32
+	// UQ112x112 cumulative price of target token at cumulativeTs, only loaded when needed
+	priceCumulative *big.Int
+	cumulativeTs    int64
+}
+
+func newPairState(d dex.Dex, tp *tokenPool, poolState *dex.State) (state *pairState, err error) {
+	price, err := d.SpotPrice(tp.pool, poolState, tp.target, tp.price)
+	if err != nil {
+		err = fmt.Errorf("SpotPrice fail:%w", err)
+		return
+	}
+
+	state = &pairState{
+		price:               price,
+		rawTargetReserve:    poolState.Reserves[tp.target],
+		rawPriceReserve:     poolState.Reserves[tp.price],
+		targetTokenDecimals: tp.pool.Decimals[tp.target],
+		priceTokenDecimals:  tp.pool.Decimals[tp.price],
+		timestamp:           poolState.Timestamp,
+	}
+	state.targetReserve = dex.DecimalAdjust(state.rawTargetReserve, state.targetTokenDecimals)
+	state.priceReserve = dex.DecimalAdjust(state.rawPriceReserve, state.priceTokenDecimals)
+	return
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// optsContext is the context of opts, background if there is none
+func optsContext(opts *bind.CallOpts) context.Context {
+	if opts == nil || opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// withContext copies opts with its context replaced by ctx
+func withContext(opts *bind.CallOpts, ctx context.Context) *bind.CallOpts {
+	copied := &bind.CallOpts{}
+	if opts != nil {
+		*copied = *opts
+	}
+	copied.Context = ctx
+	return copied
+}
@@ -0,0 +1,521 @@
+// Package pricer prices the tokens of the configured swaps in USD, other tokens or fiat currencies.
+// It is what the http server of dex-price serves, and can be embedded in other Go services.
+package pricer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/zhiqiangxu/dex-price/pkg/pricer"
+
+	// QuoteUSD is the default quote of every price
+	QuoteUSD = "usd"
+
+	defaultCacheSeconds = 1
+)
+
+// tokenPool is the pool a tokenRoute prices its target token in
+type tokenPool struct {
+	pool *dex.Pool
+	// indexes of the target and price token in pool
+	target int
+	price  int
+}
+
+type tokenRoute struct {
+	chain     *config.Chain
+	swap      *config.Swap
+	pairIndex int
+}
+
+type lpRoute struct {
+	chain   *config.Chain
+	swap    *config.Swap
+	lpIndex int
+}
+
+type lpConstant struct {
+	pool       *dex.Pool
+	token0Name string
+	token1Name string
+	decimals   uint8
+}
+
+func (r *tokenRoute) guard() *config.Guard {
+	if guard := r.swap.Pairs[r.pairIndex].Guard; guard != nil {
+		return guard
+	}
+	return r.swap.Guard
+}
+
+type priceCache struct {
+	price    float64
+	warnings []string
+	ts       int64
+}
+
+// Observer is notified of the work of a Pricer, e.g. to export metrics
+type Observer interface {
+	ObserveCache(hit bool)
+	// ObserveReserves reports the decimal adjusted reserve of token in the pool of pair on swap
+	ObserveReserves(swap, pair, token string, reserve float64)
+}
+
+// Config ...
+type Config struct {
+	Chains []*config.Chain
+	// FiatRates is a json file of the units of each fiat currency per USD, e.g. {"eur": 0.92},
+	// reloaded whenever it is modified
+	FiatRates string
+	// Backends serve the contract calls of every chain by name, Subscribe needs them to be a bind.ContractFilterer too.
+	// MaxTWAPDivergence needs them to implement BlockNumber like ethclient.Client, to read a pool at one block.
+	Backends map[string] /*chain*/ bind.ContractCaller
+	// CacheSeconds latest prices are cached for, 1 if not set
+	CacheSeconds int64
+	// Logger is log.Root() if not set
+	Logger log.Logger
+	// Observer is optional
+	Observer Observer
+}
+
+// Pricer ...
+type Pricer struct {
+	conf   Config
+	logger log.Logger
+	tracer trace.Tracer
+
+	dexes      map[*config.Swap]dex.Dex
+	routes     map[string] /*token*/ *tokenRoute
+	lpRoutes   map[string] /*token*/ *lpRoute
+	tokenNames map[common.Address]string
+	tokenAddrs map[string] /*token*/ common.Address
+	// tokenChains are the chains of tokenAddrs
+	tokenChains map[string] /*token*/ *config.Chain
+
+	stableCoins map[string] /*token*/ *config.Chain
+	// natives are the native tokens without a route
+	natives         map[string] /*token*/ *config.Chain
+	nativePoolCache map[string] /*chain*/ []*nativePool
+
+	fiat *fiatRates
+
+	mu          sync.RWMutex
+	priceCaches map[string] /*token*/ *priceCache
+
+	constantMu    sync.RWMutex
+	tokenPools    map[string] /*token*/ *tokenPool
+	lpConstants   map[string] /*token*/ *lpConstant
+	tokenDecimals map[decimalsKey]uint8
+
+	guardMu     sync.Mutex
+	guardStates map[*config.Pair]*guardState
+}
+
+// NewPricer builds the routes of every configured token
+func NewPricer(cfg Config) (p *Pricer, err error) {
+	for _, chain := range cfg.Chains {
+		if cfg.Backends[chain.Name] == nil {
+			err = fmt.Errorf("no backend for chain %s", chain.Name)
+			return
+		}
+	}
+	if cfg.CacheSeconds == 0 {
+		cfg.CacheSeconds = defaultCacheSeconds
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+
+	p = &Pricer{
+		conf:            cfg,
+		logger:          cfg.Logger,
+		tracer:          otel.Tracer(tracerName),
+		dexes:           make(map[*config.Swap]dex.Dex),
+		routes:          make(map[string]*tokenRoute),
+		lpRoutes:        make(map[string]*lpRoute),
+		tokenNames:      make(map[common.Address]string),
+		tokenAddrs:      make(map[string]common.Address),
+		tokenChains:     make(map[string]*config.Chain),
+		stableCoins:     make(map[string]*config.Chain),
+		natives:         make(map[string]*config.Chain),
+		nativePoolCache: make(map[string][]*nativePool),
+		priceCaches:     make(map[string]*priceCache),
+		tokenPools:      make(map[string]*tokenPool),
+		lpConstants:     make(map[string]*lpConstant),
+		tokenDecimals:   make(map[decimalsKey]uint8),
+		guardStates:     make(map[*config.Pair]*guardState),
+	}
+	err = p.buildRoutes()
+	if err != nil {
+		p = nil
+		return
+	}
+
+	if cfg.FiatRates != "" {
+		p.fiat, err = newFiatRates(cfg.FiatRates, p.logger)
+		if err != nil {
+			p = nil
+			err = fmt.Errorf("newFiatRates fail:%v", err)
+			return
+		}
+	}
+	return
+}
+
+func (p *Pricer) buildRoutes() (err error) {
+	routes, lpRoutes, tokenNames, tokenAddrs := p.routes, p.lpRoutes, p.tokenNames, p.tokenAddrs
+	for _, chain := range p.conf.Chains {
+		for _, swap := range chain.Swaps {
+			for i, pair := range swap.Pairs {
+				if routes[pair.TargetTokenName] != nil {
+					err = fmt.Errorf("duplicate token:%s", pair.TargetTokenName)
+					return
+				}
+				routes[pair.TargetTokenName] = &tokenRoute{chain: chain, swap: swap, pairIndex: i}
+				tokenNames[common.HexToAddress(pair.TargetTokenAddr)] = pair.TargetTokenName
+				tokenNames[common.HexToAddress(pair.PriceTokenAddr)] = pair.PriceTokenName
+				tokenAddrs[pair.TargetTokenName] = common.HexToAddress(pair.TargetTokenAddr)
+				tokenAddrs[pair.PriceTokenName] = common.HexToAddress(pair.PriceTokenAddr)
+				p.tokenChains[pair.TargetTokenName] = chain
+				p.tokenChains[pair.PriceTokenName] = chain
+			}
+			for i, lp := range swap.LPTokens {
+				if lpRoutes[lp.Name] != nil {
+					err = fmt.Errorf("duplicate lp token:%s", lp.Name)
+					return
+				}
+				lpRoutes[lp.Name] = &lpRoute{chain: chain, swap: swap, lpIndex: i}
+				tokenAddrs[lp.Name] = common.HexToAddress(lp.PairAddr)
+				p.tokenChains[lp.Name] = chain
+			}
+
+			var d dex.Dex
+			d, err = dex.New(swap, p.conf.Backends[chain.Name])
+			if err != nil {
+				err = fmt.Errorf("dex.New fail:%v", err)
+				return
+			}
+			p.dexes[swap] = d
+			_, oracle := d.(dex.Oracle)
+			_, pinnable := p.conf.Backends[chain.Name].(blockNumberReader)
+			for _, pair := range swap.Pairs {
+				if guard := routes[pair.TargetTokenName].guard(); guard == nil || guard.MaxTWAPDivergence == 0 {
+					continue
+				}
+				if !oracle {
+					err = fmt.Errorf("MaxTWAPDivergence of %s not supported, swap %s has no TWAP", pair.TargetTokenName, swap.Name)
+					return
+				}
+				if !pinnable {
+					err = fmt.Errorf("MaxTWAPDivergence of %s not supported, backend of chain %s has no BlockNumber", pair.TargetTokenName, chain.Name)
+					return
+				}
+			}
+		}
+
+		if chain.Native != nil {
+			if !common.IsHexAddress(chain.Native.Wrapped) {
+				err = fmt.Errorf("invalid wrapped native token %s of chain %s", chain.Native.Wrapped, chain.Name)
+				return
+			}
+			// the native symbol and the name of the wrapped token share a route,
+			// without any they are priced by nativePrice
+			wrapped := common.HexToAddress(chain.Native.Wrapped)
+			aliases := []string{chain.Native.Symbol}
+			if name, ok := tokenNames[wrapped]; ok && name != chain.Native.Symbol {
+				aliases = append(aliases, name)
+			} else if !ok {
+				tokenNames[wrapped] = chain.Native.Symbol
+			}
+			var route *tokenRoute
+			for _, name := range aliases {
+				if routes[name] != nil {
+					route = routes[name]
+					break
+				}
+			}
+			for _, name := range aliases {
+				if route == nil {
+					p.natives[name] = chain
+				} else if routes[name] == nil {
+					routes[name] = route
+				}
+			}
+		}
+
+		for _, stableCoin := range chain.StableCoins {
+			if p.stableCoins[stableCoin] != nil {
+				err = fmt.Errorf("duplicate stableCoin:%s", stableCoin)
+				return
+			}
+			p.stableCoins[stableCoin] = chain
+		}
+
+		if chain.Depeg != nil && p.stableCoins[chain.Depeg.Reference] != chain {
+			err = fmt.Errorf("depeg reference %s is not a stableCoin of chain %s", chain.Depeg.Reference, chain.Name)
+			return
+		}
+	}
+
+	for token, lpRoute := range lpRoutes {
+		if routes[token] != nil || p.natives[token] != nil {
+			err = fmt.Errorf("lp token %s conflicts with a pair token", token)
+			return
+		}
+		// the fair price is only manipulation resistant if the underlying tokens are priced elsewhere
+		lpAddr := common.HexToAddress(lpRoute.swap.LPTokens[lpRoute.lpIndex].PairAddr)
+		for _, route := range routes {
+			pair := route.swap.Pairs[route.pairIndex]
+			if addr, ok := dex.PoolAddress(route.swap, pair); ok && addr == lpAddr {
+				err = fmt.Errorf("lp token %s is priced through its own pool by %s, route %s through another pool", token, pair.TargetTokenName, pair.TargetTokenName)
+				return
+			}
+		}
+	}
+	return
+}
+
+// Tokens returns every token that can be priced by name, except stable coins without a route
+func (p *Pricer) Tokens() (tokens []string) {
+	for token := range p.routes {
+		tokens = append(tokens, token)
+	}
+	for token := range p.lpRoutes {
+		tokens = append(tokens, token)
+	}
+	for token := range p.natives {
+		tokens = append(tokens, token)
+	}
+	return
+}
+
+// IsToken is true if token can be priced
+func (p *Pricer) IsToken(token string) bool {
+	return p.routes[token] != nil || p.lpRoutes[token] != nil || p.stableCoins[token] != nil || p.natives[token] != nil
+}
+
+// IsQuote is true if prices can be quoted in quote, USD, a token or a fiat currency
+func (p *Pricer) IsQuote(quote string) bool {
+	_, ok := p.QuoteName(quote)
+	return ok
+}
+
+// QuoteName resolves quote to USD or a fiat currency, matched in any case, or to a token, matched exactly
+func (p *Pricer) QuoteName(quote string) (name string, ok bool) {
+	lower := strings.ToLower(quote)
+	if lower == QuoteUSD {
+		return QuoteUSD, true
+	}
+	if p.IsToken(quote) {
+		return quote, true
+	}
+	if p.fiat != nil {
+		if _, ok = p.fiat.rate(lower); ok {
+			name = lower
+		}
+	}
+	return
+}
+
+// TokenAddr is the address of a configured token
+func (p *Pricer) TokenAddr(token string) (addr common.Address, ok bool) {
+	addr, ok = p.tokenAddrs[token]
+	return
+}
+
+// TokenName is the name of the configured token at addr, empty if unknown
+func (p *Pricer) TokenName(addr common.Address) string {
+	return p.tokenNames[addr]
+}
+
+// Dex is the adapter of swap
+func (p *Pricer) Dex(swap *config.Swap) dex.Dex {
+	return p.dexes[swap]
+}
+
+// TokenChain is the name of the chain of a configured token, empty if unknown
+func (p *Pricer) TokenChain(token string) string {
+	if chain := p.tokenChains[token]; chain != nil {
+		return chain.Name
+	}
+	return ""
+}
+
+type decimalsKey struct {
+	chain string
+	addr  common.Address
+}
+
+// Decimals returns the cached decimals of an erc20 token on chain
+func (p *Pricer) Decimals(ctx context.Context, chain string, addr common.Address) (decimals uint8, err error) {
+	key := decimalsKey{chain: chain, addr: addr}
+	p.constantMu.RLock()
+	decimals, ok := p.tokenDecimals[key]
+	p.constantMu.RUnlock()
+	if ok {
+		return
+	}
+
+	backend := p.conf.Backends[chain]
+	if backend == nil {
+		err = &priceError{status: StatusNotFound, err: fmt.Errorf("chain not found:%s", chain)}
+		return
+	}
+	tokenContract, err := erc20.NewIERC20Caller(addr, backend)
+	if err != nil {
+		err = fmt.Errorf("NewIERC20Caller fail:%v", err)
+		return
+	}
+	decimals, err = tokenContract.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		err = fmt.Errorf("Decimals fail:%w", err)
+		return
+	}
+
+	p.constantMu.Lock()
+	p.tokenDecimals[key] = decimals
+	p.constantMu.Unlock()
+	return
+}
+
+// Price returns the latest USD price of token, served from cache when fresh
+func (p *Pricer) Price(ctx context.Context, token string) (price float64, warnings []string, err error) {
+	return p.Quote(ctx, token, QuoteUSD)
+}
+
+// Quote returns the latest price of token in units of quote, served from cache when fresh
+func (p *Pricer) Quote(ctx context.Context, token, quote string) (price float64, warnings []string, err error) {
+	if name, ok := p.QuoteName(quote); ok {
+		quote = name
+	}
+	key := token
+	if quote != QuoteUSD {
+		key = token + "/" + quote
+	}
+	now := time.Now().Unix()
+
+	p.mu.RLock()
+	cache := p.priceCaches[key]
+	p.mu.RUnlock()
+	if cache != nil && cache.ts+p.conf.CacheSeconds >= now {
+		p.observeCache(true)
+		return cache.price, cache.warnings, nil
+	}
+	p.observeCache(false)
+
+	price, warnings, err = p.quotePrice(&bind.CallOpts{Context: ctx}, token, quote)
+	if err != nil {
+		return
+	}
+
+	now = time.Now().Unix()
+	p.mu.Lock()
+	p.priceCaches[key] = &priceCache{price: price, warnings: warnings, ts: now}
+	p.mu.Unlock()
+	return
+}
+
+// Options of Prices
+type Options struct {
+	// Quote is the unit of every price, USD if empty
+	Quote string
+	// Strict fails on the first token that fails instead of reporting it in its Result
+	Strict bool
+	// BlockNumber prices as of a past block instead of the latest, bypassing the cache
+	BlockNumber *big.Int
+}
+
+// Result is the price of a token in Prices
+type Result struct {
+	Token    string
+	Price    float64
+	Warnings []string
+	// Err is why the token failed, see Status
+	Err error
+}
+
+// Prices prices every token, opts may be nil
+func (p *Pricer) Prices(ctx context.Context, tokens []string, opts *Options) (results []Result, err error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	quote := QuoteUSD
+	if opts.Quote != "" {
+		var ok bool
+		quote, ok = p.QuoteName(opts.Quote)
+		if !ok {
+			err = fmt.Errorf("unknown quote:%s", opts.Quote)
+			return
+		}
+	}
+
+	for _, token := range tokens {
+		result := Result{Token: token}
+		if opts.BlockNumber == nil {
+			result.Price, result.Warnings, result.Err = p.Quote(ctx, token, quote)
+		} else {
+			result.Price, result.Warnings, result.Err = p.quotePrice(&bind.CallOpts{Context: ctx, BlockNumber: opts.BlockNumber}, token, quote)
+		}
+		if result.Err != nil && opts.Strict {
+			err = result.Err
+			results = nil
+			return
+		}
+		results = append(results, result)
+	}
+	return
+}
+
+// CachedPrice is the last USD price computed for a token
+type CachedPrice struct {
+	Price    float64
+	Warnings []string
+	Time     time.Time
+}
+
+// CachedPrices returns the last USD price of every token priced so far
+func (p *Pricer) CachedPrices() map[string] /*token*/ CachedPrice {
+	prices := make(map[string]CachedPrice)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for token, cache := range p.priceCaches {
+		// prices in other quotes are keyed token/quote
+		if strings.Contains(token, "/") {
+			continue
+		}
+		prices[token] = CachedPrice{Price: cache.price, Warnings: cache.warnings, Time: time.Unix(cache.ts, 0)}
+	}
+	return prices
+}
+
+func (p *Pricer) observeCache(hit bool) {
+	if p.conf.Observer != nil {
+		p.conf.Observer.ObserveCache(hit)
+	}
+}
+
+func (p *Pricer) observeReserves(route *tokenRoute, state *pairState) {
+	if p.conf.Observer == nil {
+		return
+	}
+	pair := route.swap.Pairs[route.pairIndex]
+	name := pair.TargetTokenName + "/" + pair.PriceTokenName
+	p.conf.Observer.ObserveReserves(route.swap.Name, name, pair.TargetTokenName, state.targetReserve)
+	p.conf.Observer.ObserveReserves(route.swap.Name, name, pair.PriceTokenName, state.priceReserve)
+}
@@ -0,0 +1,90 @@
+package pricer
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// quotePrice returns the price of token in units of quote.
+// A quote token on the route of token is priced directly through the pairs in between,
+// otherwise both are priced in USD, which fiat quotes are converted from.
+func (p *Pricer) quotePrice(opts *bind.CallOpts, token, quote string) (price float64, warnings []string, err error) {
+	if quote == QuoteUSD {
+		return p.tokenPrice(opts, token, 0)
+	}
+
+	if !p.IsToken(quote) && p.fiat != nil {
+		if rate, ok := p.fiat.rate(quote); ok {
+			price, warnings, err = p.tokenPrice(opts, token, 0)
+			price *= rate
+			return
+		}
+	}
+
+	price, ok, err := p.routePrice(opts, token, quote)
+	if err != nil || ok {
+		return
+	}
+
+	price, warnings, err = p.tokenPrice(opts, token, 0)
+	if err != nil {
+		return
+	}
+	quotePrice, quoteWarnings, err := p.tokenPrice(opts, quote, 0)
+	if err != nil {
+		err = fmt.Errorf("quote %s price fail:%w", quote, err)
+		return
+	}
+	if quotePrice == 0 {
+		err = &priceError{status: StatusNoLiquidity, err: fmt.Errorf("quote %s has zero price", quote)}
+		return
+	}
+	price /= quotePrice
+	warnings = append(warnings, quoteWarnings...)
+	return
+}
+
+// routePrice multiplies the pair prices along the route of token up to quote,
+// ok is false if quote is not on the route
+func (p *Pricer) routePrice(opts *bind.CallOpts, token, quote string) (price float64, ok bool, err error) {
+	// aliases such as a native symbol share the route of the wrapped token
+	if route := p.routes[quote]; route != nil {
+		quote = route.swap.Pairs[route.pairIndex].TargetTokenName
+	}
+
+	var path []*tokenRoute
+	for current := token; current != quote; {
+		route := p.routes[current]
+		if route == nil || p.stableCoins[current] != nil || len(path) >= maxRouteHops {
+			return
+		}
+		path = append(path, route)
+		current = route.swap.Pairs[route.pairIndex].PriceTokenName
+	}
+
+	price = 1
+	for i, route := range path {
+		var state *pairState
+		state, err = p.queryPrice(opts, route)
+		if err != nil {
+			err = fmt.Errorf("queryPrice fail:%w", err)
+			return
+		}
+		// only MinReserveUSD of the guard needs the USD price of the price token
+		var priceTokenPrice float64
+		if guard := route.guard(); guard != nil && guard.MinReserveUSD > 0 {
+			priceTokenPrice, _, err = p.tokenPrice(opts, route.swap.Pairs[route.pairIndex].PriceTokenName, i+1)
+			if err != nil {
+				return
+			}
+		}
+		err = p.checkGuard(opts, route, state, priceTokenPrice)
+		if err != nil {
+			return
+		}
+		price *= state.price
+	}
+	ok = true
+	return
+}
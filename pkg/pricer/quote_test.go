@@ -0,0 +1,60 @@
+package pricer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+func TestQuoteName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatalf("TempDir fail:%v", err)
+	}
+	defer os.RemoveAll(dir)
+	fiatRates := filepath.Join(dir, "fiat.json")
+	if err = ioutil.WriteFile(fiatRates, []byte(`{"EUR": 0.9}`), 0600); err != nil {
+		t.Fatalf("WriteFile fail:%v", err)
+	}
+
+	swap := &config.Swap{Name: "uni", Factory: testFactory1.Hex(), Pairs: []*config.Pair{{
+		TargetTokenName: "WETH",
+		TargetTokenAddr: testWETH.Hex(),
+		PriceTokenName:  "USDC",
+		PriceTokenAddr:  testUSDT.Hex(),
+	}}}
+	chain := &config.Chain{Name: "eth", StableCoins: []string{"USDC"}, Swaps: []*config.Swap{swap}}
+	p, err := NewPricer(Config{Chains: []*config.Chain{chain}, FiatRates: fiatRates, Backends: map[string]bind.ContractCaller{"eth": newFakeChain()}})
+	if err != nil {
+		t.Fatalf("NewPricer fail:%v", err)
+	}
+
+	cases := []struct {
+		quote string
+		name  string
+		ok    bool
+	}{
+		{"usd", QuoteUSD, true},
+		{"USD", QuoteUSD, true},
+		{"WETH", "WETH", true},
+		{"USDC", "USDC", true},
+		// token names match exactly
+		{"weth", "", false},
+		{"eur", "eur", true},
+		{"Eur", "eur", true},
+		{"gbp", "", false},
+	}
+	for _, c := range cases {
+		name, ok := p.QuoteName(c.quote)
+		if name != c.name || ok != c.ok {
+			t.Fatalf("QuoteName(%s) %q %v, want %q %v", c.quote, name, ok, c.name, c.ok)
+		}
+		if p.IsQuote(c.quote) != c.ok {
+			t.Fatalf("IsQuote(%s) %v, want %v", c.quote, !c.ok, c.ok)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package pricer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+// Update is sent by Subscribe whenever the pool pricing a token changes
+type Update struct {
+	Token string
+	// Price in USD
+	Price       float64
+	Warnings    []string
+	BlockNumber uint64
+}
+
+// Subscribe sends an Update to sink whenever the pool token is priced in changes.
+// The price token is priced as of the update, served from cache when fresh.
+// Updates rejected by the Guard of the pair are logged and skipped.
+func (p *Pricer) Subscribe(ctx context.Context, token string, sink chan<- *Update) (sub event.Subscription, err error) {
+	route := p.routes[token]
+	if route == nil {
+		err = &priceError{status: StatusNotFound, err: fmt.Errorf("token %s has no pool to subscribe to", token)}
+		return
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	tp, err := p.tokenPool(opts, route)
+	if err != nil {
+		return
+	}
+
+	d := p.dexes[route.swap]
+	updates := make(chan *dex.Update)
+	poolSub, err := d.Subscribe(ctx, tp.pool, updates)
+	if err != nil {
+		err = fmt.Errorf("Subscribe fail:%w", err)
+		return
+	}
+
+	priceToken := route.swap.Pairs[route.pairIndex].PriceTokenName
+	sub = event.NewSubscription(func(quit <-chan struct{}) error {
+		defer poolSub.Unsubscribe()
+		for {
+			select {
+			case update := <-updates:
+				state, err := newPairState(d, tp, update.State)
+				if err != nil {
+					p.logger.Warn("subscription newPairState fail", "token", token, "err", err)
+					continue
+				}
+				priceTokenPrice, warnings, err := p.Price(ctx, priceToken)
+				if err != nil {
+					p.logger.Warn("subscription price fail", "token", priceToken, "err", err)
+					continue
+				}
+				err = p.checkGuard(opts, route, state, priceTokenPrice)
+				if err != nil {
+					p.logger.Warn("subscription update rejected", "token", token, "err", err)
+					continue
+				}
+
+				select {
+				case sink <- &Update{Token: token, Price: state.price * priceTokenPrice, Warnings: warnings, BlockNumber: update.BlockNumber}:
+				case <-quit:
+					return nil
+				}
+			case err := <-poolSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return
+}
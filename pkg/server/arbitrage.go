@@ -0,0 +1,384 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+)
+
+const defaultArbitrageInterval = 30
+
+// arbPairRecheck is how long a pair found not deployed is skipped before it is resolved again
+const arbPairRecheck = 10 * time.Minute
+
+// arbPair caches the pool of a pair for the arbitrage scanner, pool is nil if it is not deployed
+type arbPair struct {
+	pool    *dex.Pool
+	checked time.Time
+}
+
+// arbPool is a pool snapshot taken by the arbitrage scanner
+type arbPool struct {
+	swap     *config.Swap
+	addr     common.Address
+	token0   common.Address
+	token1   common.Address
+	reserve0 float64
+	reserve1 float64
+	fee      float64
+}
+
+// reserves returns the reserves of the pool for a swap from tokenIn
+func (p *arbPool) reserves(tokenIn common.Address) (in, out float64) {
+	if tokenIn == p.token0 {
+		return p.reserve0, p.reserve1
+	}
+	return p.reserve1, p.reserve0
+}
+
+func (p *arbPool) other(token common.Address) common.Address {
+	if token == p.token0 {
+		return p.token1
+	}
+	return p.token0
+}
+
+// arbPoolKey identifies a pool by swap and sorted token addresses
+type arbPoolKey struct {
+	swap   string
+	token0 common.Address
+	token1 common.Address
+}
+
+func sortTokens(a, b common.Address) (common.Address, common.Address) {
+	if bytes.Compare(a.Bytes(), b.Bytes()) < 0 {
+		return a, b
+	}
+	return b, a
+}
+
+func (s *Server) startArbitrage() {
+	for _, chain := range s.conf.Chains {
+		if chain.Arbitrage != nil {
+			s.workers.Add(1)
+			go func(chain *config.Chain) {
+				defer s.workers.Done()
+				s.runArbitrage(chain)
+			}(chain)
+		}
+	}
+}
+
+func (s *Server) runArbitrage(chain *config.Chain) {
+	interval := chain.Arbitrage.Interval
+	if interval == 0 {
+		interval = defaultArbitrageInterval
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		opportunities, err := s.scanArbitrage(chain)
+		if err != nil {
+			s.logger.Warn("scanArbitrage fail", "chain", chain.Name, "err", err)
+		} else {
+			s.publishArbitrage(chain, opportunities)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.workerCtx.Done():
+			return
+		}
+	}
+}
+
+// scanArbitrage compares every pair of configured tokens across the swaps of
+// chain, and every triangular cycle within a swap
+func (s *Server) scanArbitrage(chain *config.Chain) (opportunities []api.ArbitrageOpportunity, err error) {
+	var tokens []common.Address
+	for _, token := range chainTokens(chain) {
+		if !token.lp {
+			tokens = append(tokens, token.addr)
+		}
+	}
+
+	pools := make(map[arbPoolKey]*arbPool)
+	for _, swap := range chain.Swaps {
+		for i := range tokens {
+			for j := i + 1; j < len(tokens); j++ {
+				var pool *arbPool
+				pool, err = s.arbitragePool(swap, tokens[i], tokens[j])
+				if err != nil {
+					return
+				}
+				if pool != nil {
+					pools[arbPoolKey{swap: swap.Name, token0: pool.token0, token1: pool.token1}] = pool
+				}
+			}
+		}
+	}
+
+	now := time.Now().Unix()
+	report := func(kind string, start common.Address, cycle []*arbPool) {
+		opportunity := s.evaluateCycle(chain.Name, kind, start, cycle)
+		if opportunity == nil || opportunity.ProfitUSD < chain.Arbitrage.MinProfitUSD {
+			return
+		}
+		opportunity.Chain = chain.Name
+		opportunity.TS = now
+		opportunities = append(opportunities, *opportunity)
+	}
+
+	// the same token pair across swaps
+	for i, a := range chain.Swaps {
+		for _, b := range chain.Swaps[i+1:] {
+			for key, poolA := range pools {
+				if key.swap != a.Name {
+					continue
+				}
+				poolB := pools[arbPoolKey{swap: b.Name, token0: key.token0, token1: key.token1}]
+				if poolB == nil {
+					continue
+				}
+				report(api.ArbitrageCrossSwap, key.token0, []*arbPool{poolA, poolB})
+				report(api.ArbitrageCrossSwap, key.token0, []*arbPool{poolB, poolA})
+			}
+		}
+	}
+
+	// triangular cycles within a swap
+	for _, swap := range chain.Swaps {
+		for i := range tokens {
+			for j := i + 1; j < len(tokens); j++ {
+				for k := j + 1; k < len(tokens); k++ {
+					ab := pools[poolKey(swap, tokens[i], tokens[j])]
+					bc := pools[poolKey(swap, tokens[j], tokens[k])]
+					ca := pools[poolKey(swap, tokens[k], tokens[i])]
+					if ab == nil || bc == nil || ca == nil {
+						continue
+					}
+					report(api.ArbitrageTriangular, tokens[i], []*arbPool{ab, bc, ca})
+					report(api.ArbitrageTriangular, tokens[i], []*arbPool{ca, bc, ab})
+				}
+			}
+		}
+	}
+
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].ProfitUSD > opportunities[j].ProfitUSD
+	})
+	return
+}
+
+func poolKey(swap *config.Swap, a, b common.Address) arbPoolKey {
+	token0, token1 := sortTokens(a, b)
+	return arbPoolKey{swap: swap.Name, token0: token0, token1: token1}
+}
+
+// arbitragePool loads the reserves of the a/b pool of swap, nil if there is no such pool
+// or the swap is not a constant product dex
+func (s *Server) arbitragePool(swap *config.Swap, a, b common.Address) (pool *arbPool, err error) {
+	d := s.pricer.Dex(swap)
+	cp, ok := d.(dex.ConstantProduct)
+	if !ok {
+		return
+	}
+
+	opts := &bind.CallOpts{Context: s.workerCtx}
+	key := poolKey(swap, a, b)
+
+	s.arbMu.Lock()
+	cached := s.arbPairs[key]
+	s.arbMu.Unlock()
+	var dexPool *dex.Pool
+	if cached != nil && (cached.pool != nil || time.Since(cached.checked) < arbPairRecheck) {
+		dexPool = cached.pool
+	} else {
+		pair := &config.Pair{
+			TargetTokenName: s.pricer.TokenName(a),
+			TargetTokenAddr: a.Hex(),
+			PriceTokenName:  s.pricer.TokenName(b),
+			PriceTokenAddr:  b.Hex(),
+		}
+		dexPool, err = d.ResolvePool(opts, pair)
+		if err != nil {
+			if !errors.Is(err, dex.ErrNoPool) {
+				err = fmt.Errorf("ResolvePool fail:%w", err)
+				return
+			}
+			dexPool, err = nil, nil
+		}
+		s.arbMu.Lock()
+		s.arbPairs[key] = &arbPair{pool: dexPool, checked: time.Now()}
+		s.arbMu.Unlock()
+	}
+	if dexPool == nil {
+		return
+	}
+
+	state, err := d.State(opts, dexPool)
+	if err != nil {
+		// pairs resolved offline may not be created yet
+		if errors.Is(err, dex.ErrNoPool) {
+			s.arbMu.Lock()
+			s.arbPairs[key] = &arbPair{checked: time.Now()}
+			s.arbMu.Unlock()
+			err = nil
+			return
+		}
+		err = fmt.Errorf("State fail:%w", err)
+		return
+	}
+	if state.Reserves[0].Sign() == 0 || state.Reserves[1].Sign() == 0 {
+		return
+	}
+
+	reserve0, _ := new(big.Float).SetInt(state.Reserves[0]).Float64()
+	reserve1, _ := new(big.Float).SetInt(state.Reserves[1]).Float64()
+	pool = &arbPool{
+		swap:     swap,
+		addr:     dexPool.Address,
+		token0:   dexPool.Tokens[0],
+		token1:   dexPool.Tokens[1],
+		reserve0: reserve0,
+		reserve1: reserve1,
+		fee:      cp.Fee(dexPool),
+	}
+	return
+}
+
+// cycleTrade sizes the trade through cycle starting with token start,
+// profit is 0 if cycle does not return to start or loses money.
+//
+// A chain of constant product pools behaves like a single pool: swapping x
+// through (a, b) with fee multiplier g yields g*x*b/(a+g*x), and following it
+// by a pool (rin, rout) with multiplier g2 gives a pool with
+// a' = a*rin/(rin+g2*b), b' = g2*b*rout/(rin+g2*b). The profit of the virtual
+// pool is maximized at x = (sqrt(g*a*b)-a)/g.
+func cycleTrade(start common.Address, cycle []*arbPool) (amountIn, profit float64) {
+	token := start
+	var a, b, g float64
+	for i, pool := range cycle {
+		rin, rout := pool.reserves(token)
+		gi := 1 - pool.fee
+		if i == 0 {
+			a, b, g = rin, rout, gi
+		} else {
+			d := rin + gi*b
+			a, b = a*rin/d, gi*b*rout/d
+		}
+		token = pool.other(token)
+	}
+	if token != start || g*b <= a {
+		return 0, 0
+	}
+
+	amountIn = (math.Sqrt(g*a*b) - a) / g
+	amountOut := g * amountIn * b / (a + g*amountIn)
+	profit = amountOut - amountIn
+	if profit <= 0 {
+		return 0, 0
+	}
+	return
+}
+
+// evaluateCycle prices the trade of cycleTrade, nil if there is none
+func (s *Server) evaluateCycle(chain, kind string, start common.Address, cycle []*arbPool) *api.ArbitrageOpportunity {
+	amountIn, profit := cycleTrade(start, cycle)
+	if profit <= 0 {
+		return nil
+	}
+
+	var hops []api.ArbitrageHop
+	token := start
+	for _, pool := range cycle {
+		next := pool.other(token)
+		hops = append(hops, api.ArbitrageHop{Swap: pool.swap.Name, Pair: pool.addr.Hex(), TokenIn: s.pricer.TokenName(token), TokenOut: s.pricer.TokenName(next)})
+		token = next
+	}
+
+	name := s.pricer.TokenName(start)
+	opportunity := &api.ArbitrageOpportunity{Kind: kind, Token: name, Hops: hops}
+
+	decimals, err := s.pricer.Decimals(s.workerCtx, chain, start)
+	if err != nil {
+		s.logger.Warn("evaluateCycle decimals fail", "token", s.pricer.TokenName(start), "err", err)
+		return nil
+	}
+	scale, _ := dex.Pow10(decimals).Float64()
+	opportunity.AmountIn = amountIn / scale
+	opportunity.Profit = profit / scale
+
+	price, _, err := s.pricer.Price(s.workerCtx, name)
+	if err != nil {
+		s.logger.Warn("evaluateCycle price fail", "token", name, "err", err)
+		return nil
+	}
+	opportunity.ProfitUSD = opportunity.Profit * price
+	return opportunity
+}
+
+func (s *Server) publishArbitrage(chain *config.Chain, opportunities []api.ArbitrageOpportunity) {
+	s.arbMu.Lock()
+	defer s.arbMu.Unlock()
+
+	s.arbOpportunities[chain.Name] = opportunities
+	for sub := range s.arbSubs {
+		select {
+		case sub <- opportunities:
+		default:
+			// slow subscriber, it will catch up on the next scan
+		}
+	}
+}
+
+func (s *Server) queryArbitrageHandler(c *gin.Context) {
+	var output api.ArbitrageResult
+
+	s.arbMu.Lock()
+	for _, chain := range s.conf.Chains {
+		output.Opportunities = append(output.Opportunities, s.arbOpportunities[chain.Name]...)
+	}
+	s.arbMu.Unlock()
+
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
+
+// streamArbitrageHandler pushes the opportunities of every scan as server-sent events
+func (s *Server) streamArbitrageHandler(c *gin.Context) {
+	sub := make(chan []api.ArbitrageOpportunity, 1)
+	s.arbMu.Lock()
+	s.arbSubs[sub] = struct{}{}
+	s.arbMu.Unlock()
+	defer func() {
+		s.arbMu.Lock()
+		delete(s.arbSubs, sub)
+		s.arbMu.Unlock()
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case opportunities := <-sub:
+			c.SSEvent("arbitrage", opportunities)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		case <-s.workerCtx.Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	testTokenA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	testTokenB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+	testTokenC = common.HexToAddress("0x000000000000000000000000000000000000000c")
+)
+
+func testArbPool(token0, token1 common.Address, reserve0, reserve1, fee float64) *arbPool {
+	return &arbPool{token0: token0, token1: token1, reserve0: reserve0, reserve1: reserve1, fee: fee}
+}
+
+// the expected sizes below maximize the profit of swapping through the pools one by one, found numerically
+func TestCycleTrade(t *testing.T) {
+	cases := []struct {
+		name     string
+		cycle    []*arbPool
+		amountIn float64
+		profit   float64
+	}{
+		{"cross swap", []*arbPool{
+			testArbPool(testTokenA, testTokenB, 1000, 2000, 0.003),
+			testArbPool(testTokenA, testTokenB, 1000, 1900, 0.003),
+		}, 11.207426170372, 0.256654733122},
+		{"triangular", []*arbPool{
+			testArbPool(testTokenA, testTokenB, 500, 1000000, 0.003),
+			testArbPool(testTokenB, testTokenC, 2000000, 1000, 0.0025),
+			testArbPool(testTokenC, testTokenA, 300, 330, 0.003),
+		}, 7.047574405005, 0.312591819732},
+		{"no fee", []*arbPool{
+			testArbPool(testTokenA, testTokenB, 1000, 4000, 0),
+			testArbPool(testTokenB, testTokenA, 1000, 1000, 0),
+		}, 200, 200},
+		{"same price", []*arbPool{
+			testArbPool(testTokenA, testTokenB, 1000, 2000, 0.003),
+			testArbPool(testTokenA, testTokenB, 3000, 6000, 0.003),
+		}, 0, 0},
+		{"not a cycle", []*arbPool{
+			testArbPool(testTokenA, testTokenB, 1000, 4000, 0),
+		}, 0, 0},
+	}
+	for _, c := range cases {
+		amountIn, profit := cycleTrade(testTokenA, c.cycle)
+		if math.Abs(amountIn-c.amountIn) > 1e-9*math.Max(1, c.amountIn) || math.Abs(profit-c.profit) > 1e-9*math.Max(1, c.profit) {
+			t.Fatalf("%s: amountIn %v profit %v, want %v %v", c.name, amountIn, profit, c.amountIn, c.profit)
+		}
+	}
+}
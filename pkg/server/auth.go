@@ -0,0 +1,306 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/filewatch"
+	"github.com/zhiqiangxu/dex-price/pkg/logger"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyQuery  = "api_key"
+	// apiKeyContextKey holds the *apiKey of a request in the gin context
+	apiKeyContextKey = "api_key"
+)
+
+var defaultPublicEndpoints = []string{"/healthz", "/readyz", "/openapi.json"}
+
+// tokenBucket holds up to burst tokens, refilled at rate per second
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// take consumes a token if there is one, otherwise retryAfter is the wait for the next token
+func (b *tokenBucket) take(now time.Time) (ok bool, remaining int, retryAfter time.Duration) {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		retryAfter = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		return
+	}
+	b.tokens--
+	ok = true
+	remaining = int(b.tokens)
+	return
+}
+
+// apiKey is a configured key along with its rate limit state
+type apiKey struct {
+	conf      *config.APIKey
+	name      string
+	endpoints map[string]bool
+	// bucket is nil for unlimited keys
+	bucket *tokenBucket
+}
+
+// keyUsage counts the requests of a key, kept across reloads by key name
+type keyUsage struct {
+	requests    uint64
+	rateLimited uint64
+	forbidden   uint64
+	lastUsed    int64
+}
+
+// apiKeys authenticates and rate limits requests by the keys of Config.Auth
+type apiKeys struct {
+	conf   *config.Auth
+	logger log.Logger
+	public map[string]bool
+
+	mu      sync.Mutex
+	watcher *filewatch.Watcher
+	keys    map[string] /*key*/ *apiKey
+	usage   map[string] /*name*/ *keyUsage
+}
+
+func newAPIKeys(conf *config.Auth, logger log.Logger) (a *apiKeys, err error) {
+	a = &apiKeys{
+		conf:   conf,
+		logger: logger,
+		public: make(map[string]bool),
+		usage:  make(map[string]*keyUsage),
+	}
+	public := conf.Public
+	if len(public) == 0 {
+		public = defaultPublicEndpoints
+	}
+	for _, endpoint := range public {
+		a.public[endpoint] = true
+	}
+
+	var files []string
+	if conf.KeysFile != "" {
+		files = append(files, conf.KeysFile)
+	}
+	a.watcher = filewatch.New(a.load, files...)
+	err = a.watcher.Reload()
+	if err != nil {
+		a = nil
+	}
+	return
+}
+
+// load rebuilds keys from Keys and KeysFile, must be called with mu held or before use.
+// Buckets of keys whose limits are unchanged are kept.
+func (a *apiKeys) load() (err error) {
+	confKeys := a.conf.Keys
+	if a.conf.KeysFile != "" {
+		var jsonBytes []byte
+		jsonBytes, err = ioutil.ReadFile(a.conf.KeysFile)
+		if err != nil {
+			err = fmt.Errorf("ReadFile fail:%v", err)
+			return
+		}
+		var fileKeys []*config.APIKey
+		err = json.Unmarshal(jsonBytes, &fileKeys)
+		if err != nil {
+			err = fmt.Errorf("api keys %s Unmarshal fail:%v", a.conf.KeysFile, err)
+			return
+		}
+		confKeys = append(append([]*config.APIKey{}, confKeys...), fileKeys...)
+	}
+
+	keys := make(map[string]*apiKey)
+	names := make(map[string]bool)
+	for _, conf := range confKeys {
+		if conf.Key == "" {
+			err = fmt.Errorf("empty api key")
+			return
+		}
+		if keys[conf.Key] != nil {
+			err = fmt.Errorf("duplicate api key:%s", logger.RedactKey(conf.Key))
+			return
+		}
+		if conf.Rate < 0 || conf.Burst < 0 {
+			err = fmt.Errorf("invalid rate limit of api key:%s", logger.RedactKey(conf.Key))
+			return
+		}
+
+		key := &apiKey{conf: conf, name: conf.Name, endpoints: make(map[string]bool)}
+		if key.name == "" {
+			key.name = logger.RedactKey(conf.Key)
+		}
+		// usage is counted by name, keys sharing one would be merged
+		if names[key.name] {
+			err = fmt.Errorf("duplicate api key name:%s, set a distinct Name", key.name)
+			return
+		}
+		names[key.name] = true
+		for _, endpoint := range conf.Endpoints {
+			key.endpoints[endpoint] = true
+		}
+		if conf.Rate > 0 {
+			if old := a.keys[conf.Key]; old != nil && old.bucket != nil && old.conf.Rate == conf.Rate && old.conf.Burst == conf.Burst {
+				key.bucket = old.bucket
+			} else {
+				key.bucket = newTokenBucket(conf.Rate, conf.Burst)
+			}
+		}
+		keys[conf.Key] = key
+	}
+	a.keys = keys
+	return
+}
+
+// authorize checks key against endpoint and takes a token from its bucket.
+// On success status is 0, otherwise the http status to reject the request with.
+func (a *apiKeys) authorize(c *gin.Context, key, endpoint string) (k *apiKey, status int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.watcher.Reload(); err != nil {
+		a.logger.Warn("api keys reload fail", "file", a.conf.KeysFile, "err", err)
+	}
+
+	k = a.keys[key]
+	if k == nil {
+		status = http.StatusUnauthorized
+		if key == "" {
+			err = fmt.Errorf("api key required")
+		} else {
+			err = fmt.Errorf("invalid api key")
+		}
+		return
+	}
+
+	usage := a.usage[k.name]
+	if usage == nil {
+		usage = &keyUsage{}
+		a.usage[k.name] = usage
+	}
+	now := time.Now()
+	usage.requests++
+	usage.lastUsed = now.Unix()
+
+	if len(k.endpoints) > 0 && !k.endpoints[endpoint] {
+		usage.forbidden++
+		status = http.StatusForbidden
+		err = fmt.Errorf("endpoint %s not allowed for api key %s", endpoint, k.name)
+		return
+	}
+
+	if k.bucket != nil {
+		ok, remaining, retryAfter := k.bucket.take(now)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(int(k.bucket.burst)))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			usage.rateLimited++
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(now.Add(retryAfter).Unix(), 10))
+			status = http.StatusTooManyRequests
+			err = fmt.Errorf("rate limit of api key %s exceeded", k.name)
+			return
+		}
+	}
+	return
+}
+
+// authMiddleware rejects requests to non public routes without an allowed key within its rate limit
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	a := s.apiKeys
+	return func(c *gin.Context) {
+		endpoint := c.FullPath()
+		if a.public[endpoint] {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(apiKeyHeader)
+		if key == "" {
+			key = c.Query(apiKeyQuery)
+		}
+		k, status, err := a.authorize(c, key, endpoint)
+		if err != nil {
+			s.ctxLogger(c.Request.Context()).Debug("api key rejected", "path", c.Request.URL.Path, "status", status, "err", err)
+			c.AbortWithStatusJSON(status, gin.H{"msg": err.Error()})
+			return
+		}
+		c.Set(apiKeyContextKey, k)
+		c.Next()
+	}
+}
+
+// usageSnapshot returns the usage of every key that made a request, sorted by name
+func (a *apiKeys) usageSnapshot() (usages []api.KeyUsage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for name, usage := range a.usage {
+		usages = append(usages, api.KeyUsage{
+			Name:        name,
+			Requests:    usage.requests,
+			RateLimited: usage.rateLimited,
+			Forbidden:   usage.forbidden,
+			LastUsed:    usage.lastUsed,
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Name < usages[j].Name
+	})
+	return
+}
+
+func (s *Server) queryUsageHandler(c *gin.Context) {
+	if s.apiKeys == nil {
+		c.JSON(http.StatusNotFound, gin.H{"msg": "api keys not configured"})
+		return
+	}
+	if k, _ := c.Get(apiKeyContextKey); k == nil || !k.(*apiKey).conf.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"msg": "admin api key required"})
+		return
+	}
+
+	var output api.UsageResult
+	output.Keys = s.apiKeys.usageSnapshot()
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
+
+// requestTarget is the request uri of u with the api key in its query redacted
+func requestTarget(u *url.URL) string {
+	query := u.Query()
+	if _, ok := query[apiKeyQuery]; !ok {
+		return u.RequestURI()
+	}
+	query.Set(apiKeyQuery, "***")
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
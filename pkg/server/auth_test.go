@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	type take struct {
+		// at is the time of the take since the first one
+		at         time.Duration
+		ok         bool
+		remaining  int
+		retryAfter time.Duration
+	}
+	cases := []struct {
+		name  string
+		rate  float64
+		burst int
+		takes []take
+	}{
+		{"burst then refill", 2, 3, []take{
+			{0, true, 2, 0},
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, 500 * time.Millisecond},
+			{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+			{500 * time.Millisecond, true, 0, 0},
+			// refills stop at burst
+			{10 * time.Second, true, 2, 0},
+		}},
+		{"burst defaults to rate rounded up", 0.5, 0, []take{
+			{0, true, 0, 0},
+			{time.Second, false, 0, time.Second},
+			{2 * time.Second, true, 0, 0},
+		}},
+	}
+	for _, c := range cases {
+		bucket := newTokenBucket(c.rate, c.burst)
+		start := time.Unix(1600000000, 0)
+		for i, want := range c.takes {
+			ok, remaining, retryAfter := bucket.take(start.Add(want.at))
+			if ok != want.ok || remaining != want.remaining || retryAfter != want.retryAfter {
+				t.Fatalf("%s take %d: got %v %d %v, want %v %d %v", c.name, i, ok, remaining, retryAfter, want.ok, want.remaining, want.retryAfter)
+			}
+		}
+	}
+}
+
+func TestAPIKeysUniqueNames(t *testing.T) {
+	cases := []struct {
+		name string
+		keys []*config.APIKey
+		ok   bool
+	}{
+		{"distinct prefixes", []*config.APIKey{{Key: "abcd1"}, {Key: "bcde1"}}, true},
+		// both would be counted as abcd***
+		{"shared prefix", []*config.APIKey{{Key: "abcd1"}, {Key: "abcd2"}}, false},
+		{"shared prefix named", []*config.APIKey{{Key: "abcd1", Name: "alice"}, {Key: "abcd2", Name: "bob"}}, true},
+		{"name of another key", []*config.APIKey{{Key: "abcd1", Name: "bcde***"}, {Key: "bcde1"}}, false},
+		{"duplicate name", []*config.APIKey{{Key: "abcd1", Name: "alice"}, {Key: "bcde1", Name: "alice"}}, false},
+	}
+	for _, c := range cases {
+		_, err := newAPIKeys(&config.Auth{Keys: c.keys}, log.New())
+		if c.ok && err != nil {
+			t.Fatalf("%s: newAPIKeys fail:%v", c.name, err)
+		}
+		if !c.ok && err == nil {
+			t.Fatalf("%s: newAPIKeys accepted duplicate names", c.name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nodeCaller spreads contract calls over the ethClients of chain, bounds each by RPC.CallTimeout
+// and records them in metrics and logs
+type nodeCaller struct {
+	s     *Server
+	chain string
+}
+
+func (c nodeCaller) BlockNumber(ctx context.Context) (number uint64, err error) {
+	ctx, cancel := c.s.callContext(ctx)
+	defer cancel()
+	node, start := c.s.nextNode(c.chain), time.Now()
+	ctx, span := c.s.startRPCSpan(ctx, node, "eth_blockNumber", nil, nil)
+	defer func() { endSpan(span, err) }()
+	number, err = c.s.ethClients[node].BlockNumber(ctx)
+	err = c.s.scrubError(node, err)
+	c.s.observeRPC(ctx, node, "eth_blockNumber", start, err)
+	return
+}
+
+func (c nodeCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	ctx, cancel := c.s.callContext(ctx)
+	defer cancel()
+	node, start := c.s.nextNode(c.chain), time.Now()
+	ctx, span := c.s.startRPCSpan(ctx, node, "eth_getCode", &contract, nil)
+	defer func() { endSpan(span, err) }()
+	code, err = c.s.ethClients[node].CodeAt(ctx, contract, blockNumber)
+	err = c.s.scrubError(node, err)
+	c.s.observeRPC(ctx, node, "eth_getCode", start, err)
+	return
+}
+
+func (c nodeCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (result []byte, err error) {
+	ctx, cancel := c.s.callContext(ctx)
+	defer cancel()
+	node, start := c.s.nextNode(c.chain), time.Now()
+	ctx, span := c.s.startRPCSpan(ctx, node, "eth_call", call.To, call.Data)
+	defer func() { endSpan(span, err) }()
+	result, err = c.s.ethClients[node].CallContract(ctx, call, blockNumber)
+	err = c.s.scrubError(node, err)
+	method := "eth_call"
+	if contractMethod, ok := contractMethodOf(call.Data); ok {
+		method = contractMethod
+	}
+	c.s.observeRPC(ctx, node, method, start, err)
+	return
+}
+
+func (c nodeCaller) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	ctx, cancel := c.s.callContext(ctx)
+	defer cancel()
+	node, start := c.s.nextNode(c.chain), time.Now()
+	ctx, span := c.s.startRPCSpan(ctx, node, "eth_getLogs", nil, nil)
+	defer func() { endSpan(span, err) }()
+	logs, err = c.s.ethClients[node].FilterLogs(ctx, query)
+	err = c.s.scrubError(node, err)
+	c.s.observeRPC(ctx, node, "eth_getLogs", start, err)
+	return
+}
+
+func (c nodeCaller) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	node, start := c.s.nextNode(c.chain), time.Now()
+	sub, err = c.s.ethClients[node].SubscribeFilterLogs(ctx, query, ch)
+	err = c.s.scrubError(node, err)
+	c.s.observeRPC(ctx, node, "eth_subscribe", start, err)
+	return
+}
+
+// nodeError is an rpc error with the url of its node replaced by the node label,
+// as providers put API keys in node urls
+type nodeError struct {
+	msg string
+	err error
+}
+
+func (e *nodeError) Error() string {
+	return e.msg
+}
+
+func (e *nodeError) Unwrap() error {
+	return e.err
+}
+
+// scrubError hides the url of node in err before it reaches logs, metrics, traces or responses
+func (s *Server) scrubError(node int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nodeError{msg: strings.ReplaceAll(err.Error(), s.nodeURLs[node], s.nodeLabels[node]), err: err}
+}
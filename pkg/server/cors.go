@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/config"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodOptions}
+	defaultCORSHeaders = []string{"Content-Type", apiKeyHeader, requestIDHeader}
+	// corsExposeHeaders are the response headers browser clients may read
+	corsExposeHeaders = []string{requestIDHeader, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"}
+)
+
+const defaultCORSMaxAge = 600
+
+// corsMiddleware sets the cross origin headers for allowed origins and answers preflight requests,
+// it runs before authMiddleware as browsers send preflights without credentials
+func corsMiddleware(conf *config.CORS) gin.HandlerFunc {
+	anyOrigin := false
+	origins := make(map[string]bool)
+	for _, origin := range conf.Origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(origin)] = true
+	}
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := conf.Headers
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	maxAge := conf.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultCORSMaxAge
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(corsExposeHeaders, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		if !anyOrigin && !origins[strings.ToLower(origin)] {
+			c.Next()
+			return
+		}
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		c.Header("Access-Control-Expose-Headers", exposeHeaders)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			c.Header("Access-Control-Max-Age", strconv.FormatInt(maxAge, 10))
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
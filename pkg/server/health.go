@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+const (
+	defaultHealthInterval = 15
+	defaultMaxBlockAge    = 120
+	defaultMaxPriceAge    = 300
+	// healthCallTimeout bounds each node probe
+	healthCallTimeout = 5 * time.Second
+)
+
+// healthState is the outcome of the last background health check
+type healthState struct {
+	checkedAt int64
+	chains    map[string] /*chain*/ *chainHealth
+	// priceErrs are the errors of tokens that failed to be priced
+	priceErrs map[string] /*token*/ error
+}
+
+// chainHealth is the state of the nodes of a chain
+type chainHealth struct {
+	healthyNodes int
+	nodeErrs     []string
+	// latest block seen by any healthy node
+	blockNumber uint64
+	blockTime   int64
+}
+
+func (s *Server) healthInterval() int64 {
+	if s.conf.Health != nil && s.conf.Health.Interval > 0 {
+		return s.conf.Health.Interval
+	}
+	return defaultHealthInterval
+}
+
+func (s *Server) maxBlockAge() int64 {
+	if s.conf.Health != nil && s.conf.Health.MaxBlockAge > 0 {
+		return s.conf.Health.MaxBlockAge
+	}
+	return defaultMaxBlockAge
+}
+
+func (s *Server) maxPriceAge() int64 {
+	if s.conf.Health != nil && s.conf.Health.MaxPriceAge > 0 {
+		return s.conf.Health.MaxPriceAge
+	}
+	return defaultMaxPriceAge
+}
+
+// healthTokens are the tokens that must have a fresh price for the server to be ready
+func (s *Server) healthTokens() (tokens []string) {
+	if s.conf.Health != nil && len(s.conf.Health.Tokens) > 0 {
+		return s.conf.Health.Tokens
+	}
+	tokens = s.pricer.Tokens()
+	sort.Strings(tokens)
+	return
+}
+
+func (s *Server) runHealth() {
+	ticker := time.NewTicker(time.Duration(s.healthInterval()) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		s.checkHealth()
+		select {
+		case <-ticker.C:
+		case <-s.workerCtx.Done():
+			return
+		}
+	}
+}
+
+// checkHealth probes the latest block of every node and refreshes the price of every health token
+func (s *Server) checkHealth() {
+	state := &healthState{
+		chains:    make(map[string]*chainHealth),
+		priceErrs: make(map[string]error),
+	}
+	for _, chain := range s.conf.Chains {
+		state.chains[chain.Name] = &chainHealth{}
+	}
+
+	for node, client := range s.ethClients {
+		ch := state.chains[s.nodeChains[node]]
+		ctx, cancel := context.WithTimeout(s.workerCtx, healthCallTimeout)
+		start := time.Now()
+		header, err := client.HeaderByNumber(ctx, nil)
+		err = s.scrubError(node, err)
+		s.observeRPC(ctx, node, "eth_getBlockByNumber", start, err)
+		cancel()
+		if err != nil {
+			// /readyz is public, the error itself is only logged by observeRPC
+			ch.nodeErrs = append(ch.nodeErrs, fmt.Sprintf("%s:%s", s.nodeLabels[node], pricer.Message(err)))
+			continue
+		}
+		ch.healthyNodes++
+		if header.Number.Uint64() >= ch.blockNumber {
+			ch.blockNumber = header.Number.Uint64()
+			ch.blockTime = int64(header.Time)
+		}
+	}
+
+	for _, token := range s.healthTokens() {
+		_, _, err := s.pricer.Price(s.workerCtx, token)
+		if err != nil {
+			state.priceErrs[token] = err
+		}
+	}
+
+	state.checkedAt = time.Now().Unix()
+	s.healthMu.Lock()
+	s.health = state
+	s.healthMu.Unlock()
+}
+
+// readiness lists the components failing as of the last health check
+func (s *Server) readiness() (failing []api.FailingComponent) {
+	s.healthMu.RLock()
+	state := s.health
+	s.healthMu.RUnlock()
+	if state == nil {
+		failing = append(failing, api.FailingComponent{Component: "health", Msg: "first health check pending"})
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, chain := range s.conf.Chains {
+		ch := state.chains[chain.Name]
+		if ch.healthyNodes == 0 {
+			failing = append(failing, api.FailingComponent{
+				Component: "chain:" + chain.Name,
+				Msg:       fmt.Sprintf("no healthy node:%s", strings.Join(ch.nodeErrs, "; ")),
+			})
+			continue
+		}
+		if age := now - ch.blockTime; age > s.maxBlockAge() {
+			failing = append(failing, api.FailingComponent{
+				Component: "chain:" + chain.Name,
+				Msg:       fmt.Sprintf("latest block %d is %ds old", ch.blockNumber, age),
+			})
+		}
+	}
+
+	maxPriceAge := s.maxPriceAge()
+	prices := s.pricer.CachedPrices()
+	for _, token := range s.healthTokens() {
+		if cache, ok := prices[token]; ok && now-cache.Time.Unix() <= maxPriceAge {
+			continue
+		}
+		msg := fmt.Sprintf("no price within %ds", maxPriceAge)
+		if err := state.priceErrs[token]; err != nil {
+			msg += fmt.Sprintf(":%s:%s", pricer.Status(err), pricer.Message(err))
+		}
+		failing = append(failing, api.FailingComponent{Component: "token:" + token, Msg: msg})
+	}
+	return
+}
+
+func (s *Server) healthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, api.BaseResp{Code: http.StatusOK, Msg: "ok"})
+}
+
+func (s *Server) readyzHandler(c *gin.Context) {
+	var output api.ReadyResult
+	output.Failing = s.readiness()
+	if len(output.Failing) > 0 {
+		output.Code = http.StatusServiceUnavailable
+		output.Msg = "not ready"
+		c.JSON(http.StatusServiceUnavailable, output)
+		return
+	}
+
+	output.Ready = true
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
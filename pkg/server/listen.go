@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/filewatch"
+)
+
+const defaultBindAddr = "0.0.0.0"
+
+// listen opens the unix socket or the tcp address of httpConf, tlsConfig is nil unless TLS is configured
+func (s *Server) listen(httpConf *config.HTTP) (ln net.Listener, tlsConfig *tls.Config, err error) {
+	if httpConf.UnixSocket != "" {
+		// a socket left behind by an unclean exit makes Listen fail
+		if info, statErr := os.Stat(httpConf.UnixSocket); statErr == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(httpConf.UnixSocket)
+		}
+		ln, err = net.Listen("unix", httpConf.UnixSocket)
+	} else {
+		addr := httpConf.Addr
+		if addr == "" {
+			addr = defaultBindAddr
+		}
+		ln, err = net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.conf.Listen))))
+	}
+	if err != nil {
+		err = fmt.Errorf("Listen fail:%v", err)
+		return
+	}
+
+	if httpConf.TLS != nil {
+		var certs *certReloader
+		certs, err = newCertReloader(httpConf.TLS, s.logger)
+		if err != nil {
+			ln.Close()
+			err = fmt.Errorf("tls certificate fail:%v", err)
+			return
+		}
+		tlsConfig = &tls.Config{GetCertificate: certs.getCertificate, MinVersion: tls.VersionTLS12}
+	}
+	return
+}
+
+// certReloader serves the certificate of Config.HTTP.TLS, reloading it whenever either file is modified
+type certReloader struct {
+	conf   *config.TLS
+	logger log.Logger
+
+	mu      sync.Mutex
+	watcher *filewatch.Watcher
+	cert    *tls.Certificate
+}
+
+func newCertReloader(conf *config.TLS, logger log.Logger) (r *certReloader, err error) {
+	r = &certReloader{conf: conf, logger: logger}
+	r.watcher = filewatch.New(r.load, conf.CertFile, conf.KeyFile)
+	err = r.watcher.Reload()
+	if err != nil {
+		r = nil
+	}
+	return
+}
+
+// load reads the pair, must be called with mu held or before use
+func (r *certReloader) load() (err error) {
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		err = fmt.Errorf("LoadX509KeyPair fail:%v", err)
+		return
+	}
+	r.cert = &cert
+	return
+}
+
+// getCertificate is tls.Config.GetCertificate, a pair that fails to reload keeps serving the last good one
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.watcher.Reload(); err != nil {
+		r.logger.Warn("tls certificate reload fail", "cert", r.conf.CertFile, "key", r.conf.KeyFile, "err", err)
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/logger"
+)
+
+// requestIDHeader carries the request ID from clients and back to them
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware tags every request with an ID, taken from requestIDHeader or generated,
+// stores it in the request context for the rpc calls it causes, and logs the request once done
+func (s *Server) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+
+		start := time.Now()
+		c.Next()
+
+		s.logger.Info("request", "request_id", id, "method", c.Request.Method, "path", c.Request.URL.Path,
+			"status", c.Writer.Status(), "elapsed", time.Since(start))
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ctxLogger is the logger of the request ctx belongs to, if any
+func (s *Server) ctxLogger(ctx context.Context) log.Logger {
+	return logger.Ctx(ctx, s.logger)
+}
+
+// observeRPC records an rpc call to node in metrics and logs
+func (s *Server) observeRPC(ctx context.Context, node int, method string, start time.Time, err error) {
+	s.metrics.observeRPC(s.nodeLabels[node], method, start, err)
+
+	ctxLogger := s.ctxLogger(ctx)
+	if err != nil {
+		ctxLogger.Warn("rpc fail", "node", s.nodeLabels[node], "method", method, "elapsed", time.Since(start), "err", err)
+		return
+	}
+	ctxLogger.Debug("rpc", "node", s.nodeLabels[node], "method", method, "elapsed", time.Since(start))
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+func (s *Server) queryMarketCapHandler(c *gin.Context) {
+	tokens := strings.Split(c.Param("tokens"), ",")
+	strict := c.Query("strict") == "true"
+	quote, err := s.parseQuote(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+
+	var output api.MarketCapResult
+	output.Quote = quote
+	for _, token := range tokens {
+		marketCap, err := s.queryMarketCap(c.Request.Context(), token, quote)
+		if err != nil {
+			if strict {
+				c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"msg": pricer.Message(err)})
+				return
+			}
+			output.MarketCaps = append(output.MarketCaps, api.MarketCap{Symbol: token, Status: pricer.Status(err), Msg: pricer.Message(err)})
+			continue
+		}
+		output.MarketCaps = append(output.MarketCaps, *marketCap)
+	}
+
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
+
+// queryMarketCap values the total supply of token as its fully diluted valuation,
+// and the total supply minus the balances of its SupplyExclusions as its market cap, both in units of quote
+func (s *Server) queryMarketCap(ctx context.Context, token, quote string) (marketCap *api.MarketCap, err error) {
+	addr, ok := s.pricer.TokenAddr(token)
+	if !ok {
+		err = fmt.Errorf("token %w:%s", pricer.ErrNotFound, token)
+		return
+	}
+
+	price, warnings, err := s.pricer.Quote(ctx, token, quote)
+	if err != nil {
+		return
+	}
+
+	chain := s.pricer.TokenChain(token)
+	tokenContract, err := erc20.NewIERC20Caller(addr, nodeCaller{s: s, chain: chain})
+	if err != nil {
+		err = fmt.Errorf("NewIERC20Caller fail:%v", err)
+		return
+	}
+	decimals, err := s.pricer.Decimals(ctx, chain, addr)
+	if err != nil {
+		return
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	totalSupply, err := tokenContract.TotalSupply(opts)
+	if err != nil {
+		err = fmt.Errorf("TotalSupply fail:%w", err)
+		return
+	}
+
+	circulatingSupply := big.NewInt(0).Set(totalSupply)
+	for _, holder := range s.supplyExclusions[token] {
+		var balance *big.Int
+		balance, err = tokenContract.BalanceOf(opts, holder)
+		if err != nil {
+			err = fmt.Errorf("BalanceOf %s fail:%w", holder.Hex(), err)
+			return
+		}
+		circulatingSupply.Sub(circulatingSupply, balance)
+	}
+	// the calls may read different blocks, tokens moving between exclusions can be counted twice
+	if circulatingSupply.Sign() < 0 {
+		warnings = append(append([]string{}, warnings...), fmt.Sprintf("supply exclusions of %s exceed total supply:%s", token, totalSupply))
+		circulatingSupply.SetInt64(0)
+	}
+
+	marketCap = &api.MarketCap{
+		Symbol:            token,
+		Price:             price,
+		TotalSupply:       dex.DecimalAdjust(totalSupply, decimals),
+		CirculatingSupply: dex.DecimalAdjust(circulatingSupply, decimals),
+		Status:            api.StatusOK,
+		Warnings:          warnings,
+	}
+	marketCap.MarketCap = marketCap.CirculatingSupply * price
+	marketCap.FDV = marketCap.TotalSupply * price
+	return
+}
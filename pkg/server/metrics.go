@@ -0,0 +1,151 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "dexprice"
+
+// metrics are the prometheus metrics of a Server, served at /metrics
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	cache           *prometheus.CounterVec
+	rpcCalls        *prometheus.CounterVec
+	rpcErrors       *prometheus.CounterVec
+	rpcDuration     *prometheus.HistogramVec
+	reserves        *prometheus.GaugeVec
+}
+
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by endpoint and status code.",
+		}, []string{"endpoint", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "price_cache_total",
+			Help:      "Price cache lookups by result, hit or miss.",
+		}, []string{"result"}),
+		rpcCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_calls_total",
+			Help:      "RPC calls by node and method, contract calls by contract method.",
+		}, []string{"node", "method"}),
+		rpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_errors_total",
+			Help:      "Failed RPC calls by node and method, contract calls by contract method.",
+		}, []string{"node", "method"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_call_duration_seconds",
+			Help:      "RPC call latency by node and method, contract calls by contract method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"node", "method"}),
+		reserves: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "pool_reserve",
+			Help:      "Decimal adjusted reserve of a token in the pool of a pair, as of the last query.",
+		}, []string{"swap", "pair", "token"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.cache,
+		m.rpcCalls,
+		m.rpcErrors,
+		m.rpcDuration,
+		m.reserves,
+		newTokenCollector(s),
+	)
+	return m
+}
+
+// middleware counts and times every request by its route
+func (m *metrics) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		m.requests.WithLabelValues(endpoint, strconv.Itoa(c.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *metrics) handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveCache implements pricer.Observer
+func (m *metrics) ObserveCache(hit bool) {
+	if hit {
+		m.cache.WithLabelValues("hit").Inc()
+	} else {
+		m.cache.WithLabelValues("miss").Inc()
+	}
+}
+
+func (m *metrics) observeRPC(node, method string, start time.Time, err error) {
+	m.rpcCalls.WithLabelValues(node, method).Inc()
+	m.rpcDuration.WithLabelValues(node, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.rpcErrors.WithLabelValues(node, method).Inc()
+	}
+}
+
+// ObserveReserves implements pricer.Observer
+func (m *metrics) ObserveReserves(swap, pair, token string, reserve float64) {
+	m.reserves.WithLabelValues(swap, pair, token).Set(reserve)
+}
+
+// tokenCollector exports the last USD price of every token priced so far and its age
+type tokenCollector struct {
+	s     *Server
+	price *prometheus.Desc
+	age   *prometheus.Desc
+}
+
+func newTokenCollector(s *Server) *tokenCollector {
+	return &tokenCollector{
+		s:     s,
+		price: prometheus.NewDesc(metricsNamespace+"_token_price_usd", "Last USD price of a token.", []string{"token"}, nil),
+		age:   prometheus.NewDesc(metricsNamespace+"_token_price_age_seconds", "Seconds since the last USD price of a token was computed.", []string{"token"}, nil),
+	}
+}
+
+func (tc *tokenCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tc.price
+	ch <- tc.age
+}
+
+func (tc *tokenCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for token, cached := range tc.s.pricer.CachedPrices() {
+		ch <- prometheus.MustNewConstMetric(tc.price, prometheus.GaugeValue, cached.Price, token)
+		ch <- prometheus.MustNewConstMetric(tc.age, prometheus.GaugeValue, now.Sub(cached.Time).Seconds(), token)
+	}
+}
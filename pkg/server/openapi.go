@@ -0,0 +1,257 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+)
+
+const openAPIVersion = "3.0.3"
+
+// apiParam is a query parameter of an endpoint
+type apiParam struct {
+	name        string
+	description string
+	// typ is the json schema type of the parameter
+	typ string
+}
+
+// apiDoc documents a route of registerHandlers in the openapi spec
+type apiDoc struct {
+	summary string
+	query   []apiParam
+	// result is the json response of the route, nil if contentType is set instead
+	result      interface{}
+	contentType string
+	// statuses are the error statuses answered with {"msg": ...} besides 401/403/429 of authMiddleware
+	statuses []int
+}
+
+var (
+	quoteParam  = apiParam{name: "quote", description: "A configured token or fiat currency prices are in, usd if not set", typ: "string"}
+	strictParam = apiParam{name: "strict", description: "Fail the request with 404, or 504 if it timed out, on the first token that fails", typ: "boolean"}
+)
+
+// apiDocs must cover every route of registerHandlers, which TestAPIDocs checks
+var apiDocs = map[string] /*path*/ apiDoc{
+	"/price/:tokens": {
+		summary:  "Prices of comma separated tokens",
+		query:    []apiParam{quoteParam, strictParam},
+		result:   api.PriceResult{},
+		statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusGatewayTimeout},
+	},
+	"/tokens": {
+		summary: "Tokens that can be priced",
+		result:  api.TokensResult{},
+	},
+	"/pools/:token": {
+		summary:  "Pools on the route from token to its stable coin",
+		result:   api.PoolsResult{},
+		statuses: []int{http.StatusNotFound, http.StatusGatewayTimeout},
+	},
+	"/portfolio/:chain/:wallet": {
+		summary:  "Balances of the configured tokens held by wallet and their value",
+		query:    []apiParam{{name: "block", description: "Block number to read balances at, latest if not set", typ: "integer"}},
+		result:   api.PortfolioResult{},
+		statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout},
+	},
+	"/marketcap/:tokens": {
+		summary:  "Market caps of comma separated tokens",
+		query:    []apiParam{quoteParam, strictParam},
+		result:   api.MarketCapResult{},
+		statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusGatewayTimeout},
+	},
+	"/arbitrage": {
+		summary: "Arbitrage opportunities of the last scan of every chain",
+		result:  api.ArbitrageResult{},
+	},
+	"/arbitrage/stream": {
+		summary:     "Server-sent arbitrage events carrying the opportunities of every scan",
+		contentType: "text/event-stream",
+	},
+	"/metrics": {
+		summary:     "Prometheus metrics",
+		contentType: "text/plain",
+	},
+	"/healthz": {
+		summary: "Liveness of the process",
+		result:  api.BaseResp{},
+	},
+	"/readyz": {
+		summary: "Readiness, 503 listing the failing components if not ready",
+		result:  api.ReadyResult{},
+	},
+	"/admin/usage": {
+		summary:  "Usage of every api key, requires an admin key",
+		result:   api.UsageResult{},
+		statuses: []int{http.StatusForbidden, http.StatusNotFound},
+	},
+	"/openapi.json": {
+		summary:     "This specification",
+		contentType: "application/json",
+	},
+}
+
+type jsonObject = map[string]interface{}
+
+// openAPISpec builds the openapi document of apiDocs, with the api key scheme if Config.Auth is set
+func (s *Server) openAPISpec() jsonObject {
+	schemas := make(jsonObject)
+	schemas["Error"] = jsonObject{
+		"type":       "object",
+		"properties": jsonObject{"msg": jsonObject{"type": "string"}},
+	}
+
+	var paths []string
+	for path := range apiDocs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	pathItems := make(jsonObject)
+	for _, path := range paths {
+		doc := apiDocs[path]
+
+		var (
+			params   []jsonObject
+			segments []string
+		)
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") {
+				name := segment[1:]
+				params = append(params, jsonObject{"name": name, "in": "path", "required": true, "schema": jsonObject{"type": "string"}})
+				segment = "{" + name + "}"
+			}
+			segments = append(segments, segment)
+		}
+		for _, param := range doc.query {
+			params = append(params, jsonObject{"name": param.name, "in": "query", "description": param.description, "schema": jsonObject{"type": param.typ}})
+		}
+
+		responses := make(jsonObject)
+		if doc.result != nil {
+			content := jsonObject{"application/json": jsonObject{"schema": schemaOf(reflect.TypeOf(doc.result), schemas)}}
+			responses["200"] = jsonObject{"description": "OK", "content": content}
+			if path == "/readyz" {
+				responses["503"] = jsonObject{"description": "Not ready", "content": content}
+			}
+		} else {
+			responses["200"] = jsonObject{"description": "OK", "content": jsonObject{doc.contentType: jsonObject{}}}
+		}
+		errorContent := jsonObject{"application/json": jsonObject{"schema": jsonObject{"$ref": "#/components/schemas/Error"}}}
+		statuses := doc.statuses
+		public := s.apiKeys == nil || s.apiKeys.public[path]
+		if !public {
+			statuses = append(append([]int{}, statuses...), http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)
+		}
+		for _, status := range statuses {
+			responses[strconv.Itoa(status)] = jsonObject{"description": http.StatusText(status), "content": errorContent}
+		}
+
+		operation := jsonObject{"summary": doc.summary, "responses": responses}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if s.apiKeys != nil && public {
+			operation["security"] = []jsonObject{}
+		}
+		pathItems[strings.Join(segments, "/")] = jsonObject{"get": operation}
+	}
+
+	spec := jsonObject{
+		"openapi": openAPIVersion,
+		"info":    jsonObject{"title": "dex-price", "version": "1.0.0"},
+		"paths":   pathItems,
+	}
+	components := jsonObject{"schemas": schemas}
+	if s.apiKeys != nil {
+		components["securitySchemes"] = jsonObject{
+			"header": jsonObject{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			"query":  jsonObject{"type": "apiKey", "in": "query", "name": apiKeyQuery},
+		}
+		spec["security"] = []jsonObject{{"header": []string{}}, {"query": []string{}}}
+	}
+	spec["components"] = components
+	return spec
+}
+
+// schemaOf returns the json schema of t, adding named structs to schemas and referring to them
+func schemaOf(t reflect.Type, schemas jsonObject) jsonObject {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Bool:
+		return jsonObject{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonObject{"type": "number"}
+	case reflect.String:
+		return jsonObject{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return jsonObject{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return jsonObject{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		ref := jsonObject{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		// reserve the name first for recursive types
+		schemas[t.Name()] = nil
+		properties := make(jsonObject)
+		var required []string
+		addProperties(t, properties, &required, schemas)
+		schema := jsonObject{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		schemas[t.Name()] = schema
+		return ref
+	}
+	return jsonObject{}
+}
+
+// addProperties adds the json fields of struct t, flattening embedded structs as encoding/json does
+func addProperties(t reflect.Type, properties jsonObject, required *[]string, schemas jsonObject) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addProperties(field.Type, properties, required, schemas)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, options = tag[:i], tag[i+1:]
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := schemaOf(field.Type, schemas)
+		if kind := field.Type.Kind(); kind == reflect.Slice || kind == reflect.Map {
+			// nil slices and maps are encoded as null
+			schema["nullable"] = true
+		}
+		properties[name] = schema
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func (s *Server) openAPIHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.openAPI)
+}
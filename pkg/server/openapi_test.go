@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAPIDocs fails if a GET route of registerHandlers and apiDocs differ
+func TestAPIDocs(t *testing.T) {
+	s := &Server{}
+	s.metrics = newMetrics(s)
+	g := gin.New()
+	s.registerHandlers(g)
+
+	routes := make(map[string]bool)
+	for _, route := range g.Routes() {
+		if route.Method != http.MethodGet {
+			continue
+		}
+		routes[route.Path] = true
+		if _, ok := apiDocs[route.Path]; !ok {
+			t.Fatalf("route %s not documented in apiDocs", route.Path)
+		}
+	}
+	for path := range apiDocs {
+		if !routes[path] {
+			t.Fatalf("documented route %s not registered", path)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+// queryPoolsHandler lists the pools on the route from token to its stable coin
+func (s *Server) queryPoolsHandler(c *gin.Context) {
+	pools, err := s.pricer.Pools(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"msg": pricer.Message(err)})
+		return
+	}
+
+	var output api.PoolsResult
+	output.Pools = pools
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/dex"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+// portfolioBatchSize is the maximum number of calls in one json rpc batch
+const portfolioBatchSize = 100
+
+const nativeDecimals = 18
+
+var erc20ABI abi.ABI
+
+func init() {
+	var err error
+	erc20ABI, err = abi.JSON(strings.NewReader(erc20.IERC20ABI))
+	if err != nil {
+		panic(fmt.Sprintf("erc20 abi.JSON fail:%v", err))
+	}
+}
+
+type portfolioToken struct {
+	name string
+	addr common.Address
+	lp   bool
+}
+
+// chainTokens lists every token of chain with a known address
+func chainTokens(chain *config.Chain) (tokens []portfolioToken) {
+	seen := make(map[common.Address]bool)
+	add := func(name, addr string, lp bool) {
+		a := common.HexToAddress(addr)
+		if seen[a] {
+			return
+		}
+		seen[a] = true
+		tokens = append(tokens, portfolioToken{name: name, addr: a, lp: lp})
+	}
+	for _, swap := range chain.Swaps {
+		for _, pair := range swap.Pairs {
+			add(pair.TargetTokenName, pair.TargetTokenAddr, false)
+			add(pair.PriceTokenName, pair.PriceTokenAddr, false)
+		}
+		for _, lp := range swap.LPTokens {
+			add(lp.Name, lp.PairAddr, true)
+		}
+	}
+	return
+}
+
+func (s *Server) findChain(name string) *config.Chain {
+	for _, chain := range s.conf.Chains {
+		if chain.Name == name {
+			return chain
+		}
+	}
+	return nil
+}
+
+func (s *Server) queryPortfolioHandler(c *gin.Context) {
+	chain := s.findChain(c.Param("chain"))
+	if chain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"msg": fmt.Sprintf("chain not found:%s", c.Param("chain"))})
+		return
+	}
+	if !common.IsHexAddress(c.Param("wallet")) {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid wallet:%s", c.Param("wallet"))})
+		return
+	}
+	wallet := common.HexToAddress(c.Param("wallet"))
+
+	var (
+		priceOpts pricer.Options
+		blockArg  = "latest"
+		output    api.PortfolioResult
+	)
+	if block := c.Query("block"); block != "" {
+		number, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid block:%s", block)})
+			return
+		}
+		priceOpts.BlockNumber = big.NewInt(0).SetUint64(number)
+		blockArg = hexutil.EncodeUint64(number)
+		output.Block = number
+	}
+
+	holdings, err := s.queryBalances(c.Request.Context(), chain, wallet, blockArg)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadGateway), gin.H{"msg": fmt.Sprintf("queryBalances fail:%s", pricer.Message(err))})
+		return
+	}
+
+	var symbols []string
+	for _, holding := range holdings {
+		if holding.Status == "" {
+			symbols = append(symbols, priceSymbol(chain, holding))
+		}
+	}
+	results, err := s.pricer.Prices(c.Request.Context(), symbols, &priceOpts)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"msg": fmt.Sprintf("Prices fail:%v", err)})
+		return
+	}
+
+	for _, holding := range holdings {
+		if holding.Status == "" {
+			result := results[0]
+			results = results[1:]
+			if result.Err != nil {
+				holding.Status = pricer.Status(result.Err)
+				holding.Msg = pricer.Message(result.Err)
+			} else {
+				holding.Status = api.StatusOK
+				holding.Price = result.Price
+				holding.Value = result.Price * holding.Balance
+				holding.Warnings = result.Warnings
+			}
+		}
+
+		if holding.Status == api.StatusOK {
+			output.Holdings = append(output.Holdings, *holding)
+			output.TotalValue += holding.Value
+		} else {
+			output.Unpriced = append(output.Unpriced, *holding)
+		}
+	}
+
+	output.Wallet = wallet.Hex()
+	output.Code = http.StatusOK
+	c.JSON(http.StatusOK, output)
+}
+
+// queryBalances reads the native balance and the balance of every known token
+// of chain in json rpc batches, zero balances are left out
+func (s *Server) queryBalances(ctx context.Context, chain *config.Chain, wallet common.Address, blockArg string) (holdings []*api.Holding, err error) {
+	tokens := chainTokens(chain)
+
+	balanceOf, err := erc20ABI.Pack("balanceOf", wallet)
+	if err != nil {
+		err = fmt.Errorf("Pack balanceOf fail:%v", err)
+		return
+	}
+	decimals, err := erc20ABI.Pack("decimals")
+	if err != nil {
+		err = fmt.Errorf("Pack decimals fail:%v", err)
+		return
+	}
+
+	var nativeBalance hexutil.Big
+	results := make([]hexutil.Bytes, 2*len(tokens))
+	elems := []rpc.BatchElem{{Method: "eth_getBalance", Args: []interface{}{wallet, blockArg}, Result: &nativeBalance}}
+	for i, token := range tokens {
+		elems = append(elems,
+			rpc.BatchElem{Method: "eth_call", Args: []interface{}{callArg(token.addr, balanceOf), blockArg}, Result: &results[2*i]},
+			rpc.BatchElem{Method: "eth_call", Args: []interface{}{callArg(token.addr, decimals), blockArg}, Result: &results[2*i+1]},
+		)
+	}
+
+	node := s.nextNode(chain.Name)
+	for start := 0; start < len(elems); start += portfolioBatchSize {
+		end := start + portfolioBatchSize
+		if end > len(elems) {
+			end = len(elems)
+		}
+		callStart := time.Now()
+		batchCtx, cancel := s.callContext(ctx)
+		batchCtx, span := s.startRPCSpan(batchCtx, node, "batch", nil, nil)
+		err = s.rpcClients[node].BatchCallContext(batchCtx, elems[start:end])
+		err = s.scrubError(node, err)
+		endSpan(span, err)
+		cancel()
+		s.observeRPC(ctx, node, "batch", callStart, err)
+		if err != nil {
+			err = fmt.Errorf("BatchCall fail:%w", err)
+			return
+		}
+	}
+
+	if elems[0].Error != nil {
+		err = fmt.Errorf("eth_getBalance fail:%v", elems[0].Error)
+		return
+	}
+	if balance := (*big.Int)(&nativeBalance); balance.Sign() > 0 {
+		holding := &api.Holding{
+			Symbol:     "native",
+			RawBalance: balance.String(),
+			Balance:    dex.DecimalAdjust(balance, nativeDecimals),
+		}
+		if chain.Native != nil {
+			holding.Symbol = "native-" + chain.Native.Symbol
+		} else {
+			holding.Status = api.StatusNotFound
+			holding.Msg = fmt.Sprintf("native token of chain %s not configured", chain.Name)
+		}
+		holdings = append(holdings, holding)
+	}
+
+	for i, token := range tokens {
+		holding := &api.Holding{Symbol: token.name, Addr: token.addr.Hex()}
+		if callErr := elems[1+2*i].Error; callErr != nil {
+			holding.Status = api.StatusRPCError
+			holding.Msg = fmt.Sprintf("balanceOf fail:%v", callErr)
+			holdings = append(holdings, holding)
+			continue
+		}
+		balance := big.NewInt(0).SetBytes(results[2*i])
+		if balance.Sign() == 0 {
+			continue
+		}
+		holding.RawBalance = balance.String()
+		if callErr := elems[2+2*i].Error; callErr != nil {
+			holding.Status = api.StatusRPCError
+			holding.Msg = fmt.Sprintf("decimals fail:%v", callErr)
+			holdings = append(holdings, holding)
+			continue
+		}
+		holding.Balance = dex.DecimalAdjust(balance, uint8(big.NewInt(0).SetBytes(results[2*i+1]).Uint64()))
+		holdings = append(holdings, holding)
+	}
+	return
+}
+
+// priceSymbol is the token holding is priced as, the native balance is labeled
+// apart from its wrapped token but shares its price
+func priceSymbol(chain *config.Chain, holding *api.Holding) string {
+	if holding.Addr == "" {
+		return chain.Native.Symbol
+	}
+	return holding.Symbol
+}
+
+func callArg(to common.Address, data []byte) map[string]interface{} {
+	return map[string]interface{}{"to": to, "data": hexutil.Bytes(data)}
+}
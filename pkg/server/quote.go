@@ -0,0 +1,22 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+// parseQuote reads the quote query parameter, a configured token or fiat currency
+func (s *Server) parseQuote(c *gin.Context) (quote string, err error) {
+	raw := c.Query("quote")
+	if raw == "" {
+		quote = pricer.QuoteUSD
+		return
+	}
+	quote, ok := s.pricer.QuoteName(raw)
+	if !ok {
+		err = fmt.Errorf("unknown quote:%s", raw)
+	}
+	return
+}
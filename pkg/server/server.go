@@ -1,34 +1,30 @@
 package server
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gin-gonic/gin"
 	"github.com/zhiqiangxu/dex-price/config"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/logger"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type tokenConstant struct {
-	pairAddr            common.Address
-	targetTokenDecimals uint8
-	priceTokenDecimals  uint8
-	targetTokenIs0      bool
-}
-
-type tokenRoute struct {
-	chain     *config.Chain
-	swap      *config.Swap
-	pairIndex int
-}
-
-type priceCache struct {
-	price float64
-	ts    int64
-}
-
 // Server ...
 type Server struct {
 	ethClientIndex int64
@@ -36,70 +32,249 @@ type Server struct {
 	conf *config.Config
 	g    *gin.Engine
 
-	routes map[string] /*token*/ *tokenRoute
-
-	mu          sync.RWMutex
-	priceCaches map[string] /*token*/ *priceCache
+	pricer *pricer.Pricer
 
-	constantMu     sync.RWMutex
-	tokenConstants map[string] /*token*/ *tokenConstant
+	supplyExclusions map[string] /*token*/ []common.Address
 
 	ethClients []*ethclient.Client
+	rpcClients []*rpc.Client
+	// nodeURLs are the urls of ethClients, they may carry API keys and must not be exposed
+	nodeURLs []string
+	// nodeLabels identify ethClients in metrics and logs
+	nodeLabels []string
+	// nodeChains are the chains of ethClients
+	nodeChains []string
+	// chainNodes are the indexes of ethClients by chain
+	chainNodes map[string] /*chain*/ []int
+	metrics    *metrics
+	logger     log.Logger
+	tracer     trace.Tracer
 
-	stableCoins map[string]bool
+	// apiKeys is nil if Config.Auth is not set
+	apiKeys *apiKeys
+	// openAPI is the spec served at /openapi.json
+	openAPI jsonObject
+
+	arbMu            sync.Mutex
+	arbPairs         map[arbPoolKey]*arbPair
+	arbOpportunities map[string] /*chain*/ []api.ArbitrageOpportunity
+	arbSubs          map[chan []api.ArbitrageOpportunity]struct{}
+
+	healthMu sync.RWMutex
+	health   *healthState
+
+	httpServer *http.Server
+	// workerCtx is cancelled on shutdown to stop the background workers
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+	workers       sync.WaitGroup
 }
 
 func New(conf *config.Config) *Server {
 	g := gin.New()
 	g.Use(gin.Recovery())
 
-	var ethClients []*ethclient.Client
-	routes := make(map[string]*tokenRoute)
-	stableCoins := make(map[string]bool)
+	var (
+		ethClients []*ethclient.Client
+		rpcClients []*rpc.Client
+		nodeURLs   []string
+		nodeLabels []string
+		nodeChains []string
+	)
+	chainNodes := make(map[string][]int)
+	supplyExclusions := make(map[string][]common.Address)
 	for _, chain := range conf.Chains {
-		for _, swap := range chain.Swaps {
-			for i, pair := range swap.Pairs {
-				if routes[pair.TargetTokenName] != nil {
-					log.Fatal(fmt.Sprintf("duplicate token:%s", pair.TargetTokenName))
-				}
-				routes[pair.TargetTokenName] = &tokenRoute{chain: chain, swap: swap, pairIndex: i}
+		if len(chain.Nodes) == 0 {
+			log.Crit(fmt.Sprintf("chain %s has no nodes", chain.Name))
+		}
+		for _, node := range chain.Nodes {
+			label := logger.RedactURL(node)
+			client, err := rpc.Dial(node)
+			if err != nil {
+				// the url may carry an api key, keep it out of the log
+				log.Crit("rpc.Dial failed", "node", label, "err", strings.ReplaceAll(err.Error(), node, label))
 			}
+			chainNodes[chain.Name] = append(chainNodes[chain.Name], len(ethClients))
+			rpcClients = append(rpcClients, client)
+			ethClients = append(ethClients, ethclient.NewClient(client))
+			nodeURLs = append(nodeURLs, node)
+			nodeLabels = append(nodeLabels, label)
+			nodeChains = append(nodeChains, chain.Name)
 		}
 
-		if chain.Name == "eth" {
-			for _, node := range chain.Nodes {
-				client, err := ethclient.Dial(node)
-				if err != nil {
-					log.Fatal(fmt.Sprintf("ethclient.Dial failed:%v", err))
+		for token, holders := range chain.SupplyExclusions {
+			for _, holder := range holders {
+				if !common.IsHexAddress(holder) {
+					log.Crit(fmt.Sprintf("invalid supply exclusion %s for %s", holder, token))
 				}
-				ethClients = append(ethClients, client)
+				supplyExclusions[token] = append(supplyExclusions[token], common.HexToAddress(holder))
 			}
-		} else {
-			log.Fatal(fmt.Sprintf("chain %s not supported yet", chain.Name))
 		}
+	}
 
-		for _, stableCoin := range chain.StableCoins {
-			if stableCoins[stableCoin] {
-				log.Fatal(fmt.Sprintf("duplicate stableCoin:%s", stableCoin))
-			}
-			stableCoins[stableCoin] = true
+	serverLogger := log.New("module", "server")
+	var keys *apiKeys
+	if conf.Auth != nil {
+		var err error
+		keys, err = newAPIKeys(conf.Auth, serverLogger)
+		if err != nil {
+			log.Crit(fmt.Sprintf("newAPIKeys failed:%v", err))
 		}
 	}
-
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
 	s := &Server{
-		conf:           conf,
-		g:              g,
-		routes:         routes,
-		priceCaches:    make(map[string]*priceCache),
-		tokenConstants: make(map[string]*tokenConstant),
-		ethClients:     ethClients,
-		stableCoins:    stableCoins}
+		conf:             conf,
+		g:                g,
+		supplyExclusions: supplyExclusions,
+		ethClients:       ethClients,
+		rpcClients:       rpcClients,
+		nodeURLs:         nodeURLs,
+		nodeLabels:       nodeLabels,
+		nodeChains:       nodeChains,
+		chainNodes:       chainNodes,
+		apiKeys:          keys,
+		logger:           serverLogger,
+		workerCtx:        workerCtx,
+		cancelWorkers:    cancelWorkers,
+		tracer:           otel.Tracer(tracerName),
+		arbPairs:         make(map[arbPoolKey]*arbPair),
+		arbOpportunities: make(map[string][]api.ArbitrageOpportunity),
+		arbSubs:          make(map[chan []api.ArbitrageOpportunity]struct{})}
+	s.metrics = newMetrics(s)
+	backends := make(map[string]bind.ContractCaller)
+	for _, chain := range conf.Chains {
+		backends[chain.Name] = nodeCaller{s: s, chain: chain.Name}
+	}
+	p, err := pricer.NewPricer(pricer.Config{
+		Chains:    conf.Chains,
+		FiatRates: conf.FiatRates,
+		Backends:  backends,
+		Logger:    log.New("module", "pricer"),
+		Observer:  s.metrics,
+	})
+	if err != nil {
+		log.Crit(fmt.Sprintf("NewPricer failed:%v", err))
+	}
+	s.pricer = p
+	if conf.Health != nil {
+		for _, token := range conf.Health.Tokens {
+			if !s.pricer.IsToken(token) {
+				log.Crit(fmt.Sprintf("unknown health token:%s", token))
+			}
+		}
+	}
+	g.Use(s.requestIDMiddleware(), s.tracingMiddleware(), s.metrics.middleware(), s.timeoutMiddleware())
+	if conf.HTTP != nil && conf.HTTP.CORS != nil {
+		g.Use(corsMiddleware(conf.HTTP.CORS))
+	}
+	if s.apiKeys != nil {
+		g.Use(s.authMiddleware())
+	}
 	s.registerHandlers(g)
+	s.openAPI = s.openAPISpec()
 
 	return s
 }
 
+const (
+	defaultReadHeaderTimeout = 10
+	defaultReadTimeout       = 30
+	defaultIdleTimeout       = 120
+	defaultShutdownTimeout   = 30
+)
+
+func seconds(value, defaultValue int64) time.Duration {
+	if value == 0 {
+		value = defaultValue
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Start serves until SIGINT/SIGTERM or a listen failure, then shuts down
 func (s *Server) Start() (err error) {
-	s.g.Run(fmt.Sprintf("0.0.0.0:%d", s.conf.Listen))
+	httpConf := s.conf.HTTP
+	if httpConf == nil {
+		httpConf = &config.HTTP{}
+	}
+	ln, tlsConfig, err := s.listen(httpConf)
+	if err != nil {
+		s.stopWorkers()
+		return
+	}
+	s.httpServer = &http.Server{
+		Handler:           s.g,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: seconds(httpConf.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		ReadTimeout:       seconds(httpConf.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      seconds(httpConf.WriteTimeout, 0),
+		IdleTimeout:       seconds(httpConf.IdleTimeout, defaultIdleTimeout),
+	}
+	s.logger.Info("listening", "addr", ln.Addr(), "tls", tlsConfig != nil)
+
+	s.startWorkers()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			// the certificate comes from TLSConfig.GetCertificate
+			serveErr <- s.httpServer.ServeTLS(ln, "", "")
+		} else {
+			serveErr <- s.httpServer.Serve(ln)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err = <-serveErr:
+		if err == http.ErrServerClosed {
+			// Shutdown was called by the embedder
+			err = nil
+			return
+		}
+		err = fmt.Errorf("Serve fail:%v", err)
+		s.stopWorkers()
+		return
+	case sig := <-signals:
+		s.logger.Info("shutting down", "signal", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), seconds(httpConf.ShutdownTimeout, defaultShutdownTimeout))
+	defer cancel()
+	err = s.Shutdown(ctx)
+	return
+}
+
+// Shutdown stops the background workers, drains in-flight requests until ctx is done and closes the node clients
+func (s *Server) Shutdown(ctx context.Context) (err error) {
+	// cancelling the workers also ends the long lived /arbitrage/stream responses
+	s.cancelWorkers()
+	if s.httpServer != nil {
+		err = s.httpServer.Shutdown(ctx)
+		if err != nil {
+			err = fmt.Errorf("http Shutdown fail:%v", err)
+		}
+	}
+	s.stopWorkers()
 	return
 }
+
+func (s *Server) startWorkers() {
+	s.startArbitrage()
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		s.runHealth()
+	}()
+}
+
+// stopWorkers waits for the background workers to return, then closes the node clients
+func (s *Server) stopWorkers() {
+	s.cancelWorkers()
+	s.workers.Wait()
+	for _, client := range s.ethClients {
+		client.Close()
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/api"
+	"github.com/zhiqiangxu/dex-price/pkg/pricer"
+)
+
+const (
+	defaultRequestTimeout = 30
+	defaultCallTimeout    = 10
+)
+
+// timeoutMiddleware bounds the rpc calls of every request by HTTP.RequestTimeout,
+// except the long lived /arbitrage/stream
+func (s *Server) timeoutMiddleware() gin.HandlerFunc {
+	var requestTimeout int64
+	if s.conf.HTTP != nil {
+		requestTimeout = s.conf.HTTP.RequestTimeout
+	}
+	timeout := seconds(requestTimeout, defaultRequestTimeout)
+	return func(c *gin.Context) {
+		if c.FullPath() == "/arbitrage/stream" {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// callContext bounds a single rpc call by RPC.CallTimeout
+func (s *Server) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var callTimeout int64
+	if s.conf.RPC != nil {
+		callTimeout = s.conf.RPC.CallTimeout
+	}
+	return context.WithTimeout(ctx, seconds(callTimeout, defaultCallTimeout))
+}
+
+// errorStatus is status for a request failed with err, or 504 if it ran out of time
+func errorStatus(err error, status int) int {
+	if pricer.Status(err) == api.StatusTimeout {
+		return http.StatusGatewayTimeout
+	}
+	return status
+}
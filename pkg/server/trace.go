@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/balancer"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/curve"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/erc20"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/solidly"
+	"github.com/zhiqiangxu/dex-price/pkg/abi/uni"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zhiqiangxu/dex-price/pkg/server"
+
+// contractMethods names the methods of every binding by selector, so that contract call spans
+// and metrics tell GetPair, Decimals and GetReserves apart
+var contractMethods = make(map[[4]byte]string)
+
+func init() {
+	for _, abiJSON := range []string{
+		uni.IUniswapV2FactoryABI,
+		uni.IUniswapV2PairABI,
+		erc20.IERC20ABI,
+		curve.ICurvePoolABI,
+		balancer.IBalancerVaultABI,
+		balancer.IWeightedPoolABI,
+		solidly.ISolidlyFactoryABI,
+		solidly.ISolidlyPairABI,
+	} {
+		parsed, err := abi.JSON(strings.NewReader(abiJSON))
+		if err != nil {
+			panic(fmt.Sprintf("abi.JSON fail:%v", err))
+		}
+		for _, method := range parsed.Methods {
+			var selector [4]byte
+			copy(selector[:], method.ID)
+			contractMethods[selector] = method.RawName
+		}
+	}
+}
+
+// contractMethodOf names the contract method data calls, if known
+func contractMethodOf(data []byte) (method string, ok bool) {
+	if len(data) < 4 {
+		return
+	}
+	var selector [4]byte
+	copy(selector[:], data)
+	method, ok = contractMethods[selector]
+	return
+}
+
+// tracingMiddleware starts the span of every request, continuing the trace of the caller if any
+func (s *Server) tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := s.tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("http.target", requestTarget(c.Request.URL)),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		span.SetAttributes(attribute.Int("http.status_code", c.Writer.Status()))
+		if c.Writer.Status() >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+}
+
+// startRPCSpan starts the span of an rpc call to node, named after the contract method called if known
+func (s *Server) startRPCSpan(ctx context.Context, node int, method string, to *common.Address, data []byte) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	name := method
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.node", s.nodeLabels[node]),
+		attribute.String("rpc.method", method),
+	}
+	if to != nil {
+		attrs = append(attrs, attribute.String("contract.address", to.Hex()))
+	}
+	if contractMethod, ok := contractMethodOf(data); ok {
+		name = contractMethod
+		attrs = append(attrs, attribute.String("contract.method", contractMethod))
+	}
+	return s.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
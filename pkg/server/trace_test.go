@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestContractMethodOf(t *testing.T) {
+	cases := []struct {
+		data   string
+		method string
+		ok     bool
+	}{
+		{"0x0902f1ac", "getReserves", true},
+		// balanceOf with its address argument
+		{"0x70a08231000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", "balanceOf", true},
+		{"0x12345678", "", false},
+		{"0x0902f1", "", false},
+	}
+	for _, c := range cases {
+		method, ok := contractMethodOf(hexutil.MustDecode(c.data))
+		if method != c.method || ok != c.ok {
+			t.Fatalf("contractMethodOf(%s) %q %v, want %q %v", c.data, method, ok, c.method, c.ok)
+		}
+	}
+}